@@ -11,18 +11,46 @@ import (
 type Client struct {
 	gh *http.Client
 
+	// offline is set when responses are replayed from an archive, in which
+	// case no rate limiting is applied.
+	offline bool
+
 	rateLimitMtx sync.Mutex
 	rateLimit    ApiRateLimitRule
 }
 
-func NewClient(token string) *Client {
+// NewClient returns a client that authenticates with the passed token.  All
+// successful responses are archived when an archive is passed.
+func NewClient(token string, archive *Archive) *Client {
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{
 			AccessToken: token,
 		})
 	gh := oauth2.NewClient(context.Background(), ts)
+	gh.Transport = &metricsTransport{base: gh.Transport}
+	if archive != nil {
+		gh.Transport = &archiveTransport{
+			base:    gh.Transport,
+			archive: archive,
+		}
+	}
 
 	return &Client{
 		gh: gh,
 	}
 }
+
+// NewReplayClient returns a client that serves the most recently archived
+// response of every request from the passed archive without any network
+// access.  Requests for endpoints that were never archived fail with
+// ErrNotArchived.
+func NewReplayClient(archive *Archive) *Client {
+	return &Client{
+		gh: &http.Client{
+			Transport: &replayTransport{
+				archive: archive,
+			},
+		},
+		offline: true,
+	}
+}
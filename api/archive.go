@@ -0,0 +1,514 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	archiveIndexFilename = "index.jsonl"
+	archiveObjectsDir    = "objects"
+	archiveObjectExt     = ".json.gz"
+)
+
+// ErrNotArchived is returned when a response is requested from the archive
+// for an endpoint that was never archived.
+var ErrNotArchived = errors.New("endpoint not archived")
+
+// ArchiveEntry records a single response that was fetched from the GitHub API.
+// The response payload is stored under its SHA-256 hash, so identical payloads
+// fetched at different times are only stored once.
+type ArchiveEntry struct {
+	Endpoint string `json:"endpoint"` // Requested URL
+	Fetched  int64  `json:"fetched"`  // UNIX timestamp of the fetch
+	Hash     string `json:"sha256"`   // Hex encoded SHA-256 of the payload
+}
+
+// Archive stores the raw JSON responses fetched from the GitHub API on disk.
+// Payloads are gzip compressed and content-addressed by their SHA-256 hash,
+// and an append-only index maps every endpoint to the payloads fetched from it
+// over time.  The index is only rewritten when users are erased or commit
+// messages are trimmed.
+type Archive struct {
+	dir string
+
+	mtx      sync.Mutex
+	index    *os.File
+	entries  map[string][]ArchiveEntry // Entries by endpoint in fetch order
+	erasures []Erasure                 // Applied to all stored payloads
+	matcher  *erasureMatcher           // Matcher of erasures
+}
+
+// OpenArchive opens the archive in the passed directory, creating it when it
+// does not exist yet.
+func OpenArchive(dir string) (*Archive, error) {
+	err := os.MkdirAll(filepath.Join(dir, archiveObjectsDir), 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Archive{
+		dir:     dir,
+		entries: make(map[string][]ArchiveEntry),
+		matcher: newErasureMatcher(nil),
+	}
+	a.index, err = a.openIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(a.index)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var entry ArchiveEntry
+		err := json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			// A partially written trailing line is left behind
+			// when the process is killed during a write.
+			log.Warnf("Skipping invalid archive index entry: %v", err)
+			continue
+		}
+		a.entries[entry.Endpoint] = append(a.entries[entry.Endpoint],
+			entry)
+	}
+	if err := scanner.Err(); err != nil {
+		a.index.Close()
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Close closes the archive index.
+func (a *Archive) Close() error {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	return a.index.Close()
+}
+
+// openIndex opens the index for reading and appending.
+func (a *Archive) openIndex() (*os.File, error) {
+	return os.OpenFile(filepath.Join(a.dir, archiveIndexFilename),
+		os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+}
+
+// objectPath returns the path of the payload with the passed hash.  Payloads
+// are spread over subdirectories by the first byte of their hash.
+func (a *Archive) objectPath(hash string) string {
+	return filepath.Join(a.dir, archiveObjectsDir, hash[:2],
+		hash+archiveObjectExt)
+}
+
+// Store archives the payload fetched from the passed endpoint at the passed
+// time.  Erased users are scrubbed from the payload first, and payloads that
+// belong to a purged user are not archived.
+func (a *Archive) Store(endpoint string, fetched time.Time, payload []byte) error {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	if !a.matcher.empty() {
+		var purged bool
+		var err error
+		payload, _, purged, err = a.matcher.scrubPayload(payload)
+		if err != nil {
+			return err
+		}
+		if purged {
+			return nil
+		}
+	}
+
+	sum := sha256.Sum256(payload)
+	entry := ArchiveEntry{
+		Endpoint: endpoint,
+		Fetched:  fetched.Unix(),
+		Hash:     hex.EncodeToString(sum[:]),
+	}
+
+	err := a.writeObject(entry.Hash, payload)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = a.index.Write(append(b, '\n'))
+	if err != nil {
+		return err
+	}
+	a.entries[endpoint] = append(a.entries[endpoint], entry)
+
+	return nil
+}
+
+// writeObject writes the compressed payload unless a payload with the same
+// hash was already stored.  The payload is written to a temporary file first
+// so that a partially written object is never visible under its hash.
+//
+// This function must be called with the archive mutex held.
+func (a *Archive) writeObject(hash string, payload []byte) error {
+	path := a.objectPath(hash)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err = zw.Write(payload)
+	if err != nil {
+		return err
+	}
+	err = zw.Close()
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	err = ioutil.WriteFile(tmp, buf.Bytes(), 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// AddErasures scrubs the passed erasures from all payloads that are stored from
+// now on.  Payloads that were already archived are not modified, see Erase.
+func (a *Archive) AddErasures(erasures ...Erasure) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	a.erasures = append(a.erasures, erasures...)
+	a.matcher = newErasureMatcher(a.erasures)
+}
+
+// Erase scrubs the passed erasure from every archived payload and from all
+// payloads that are stored from now on.  Scrubbed payloads are stored under
+// their new hash, entries of payloads that belong to a purged user are
+// removed, and the index is rewritten.  Payloads that are no longer referenced
+// are deleted.  The number of rewritten or removed entries is returned.
+func (a *Archive) Erase(erasure Erasure) (int, error) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	a.erasures = append(a.erasures, erasure)
+	a.matcher = newErasureMatcher(a.erasures)
+	m := newErasureMatcher([]Erasure{erasure})
+
+	return a.rewrite(m.scrubPayload)
+}
+
+// rewrite applies the passed scrub function to every archived payload.  The
+// function returns the scrubbed payload, whether it was changed, and whether
+// the payload must be removed entirely.  Scrubbed payloads are stored under
+// their new hash, entries of removed payloads are dropped, and the index is
+// rewritten.  Payloads that are no longer referenced are deleted.  The number
+// of rewritten or removed entries is returned.
+//
+// This function must be called with the archive mutex held.
+func (a *Archive) rewrite(scrub func([]byte) ([]byte, bool, bool, error)) (int, error) {
+	// Scrub every payload once.  An empty hash marks a removed payload.
+	hashes := make(map[string]string)
+	var n int
+	for endpoint, entries := range a.entries {
+		kept := make([]ArchiveEntry, 0, len(entries))
+		for _, entry := range entries {
+			hash, ok := hashes[entry.Hash]
+			if !ok {
+				var err error
+				hash, err = a.scrubObject(scrub, entry.Hash)
+				if err != nil {
+					return n, err
+				}
+				hashes[entry.Hash] = hash
+			}
+			if hash != entry.Hash {
+				n++
+			}
+			if hash == "" {
+				continue
+			}
+			entry.Hash = hash
+			kept = append(kept, entry)
+		}
+		if len(kept) == 0 {
+			delete(a.entries, endpoint)
+			continue
+		}
+		a.entries[endpoint] = kept
+	}
+	if n == 0 {
+		return 0, nil
+	}
+
+	err := a.rewriteIndex()
+	if err != nil {
+		return n, err
+	}
+
+	// Delete the replaced payloads that are no longer referenced.
+	referenced := make(map[string]struct{})
+	for _, entries := range a.entries {
+		for _, entry := range entries {
+			referenced[entry.Hash] = struct{}{}
+		}
+	}
+	for old, hash := range hashes {
+		if _, ok := referenced[old]; ok || old == hash {
+			continue
+		}
+		err := os.Remove(a.objectPath(old))
+		if err != nil && !os.IsNotExist(err) {
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+// scrubObject applies the passed scrub function to the payload with the
+// passed hash and returns the hash of the scrubbed payload, which is empty
+// when the payload is removed.
+//
+// This function must be called with the archive mutex held.
+func (a *Archive) scrubObject(scrub func([]byte) ([]byte, bool, bool, error), hash string) (string, error) {
+	payload, err := a.Load(hash)
+	if err != nil {
+		return "", err
+	}
+	payload, changed, purged, err := scrub(payload)
+	if err != nil {
+		return "", err
+	}
+	if purged {
+		return "", nil
+	}
+	if !changed {
+		return hash, nil
+	}
+
+	sum := sha256.Sum256(payload)
+	scrubbedHash := hex.EncodeToString(sum[:])
+	err = a.writeObject(scrubbedHash, payload)
+	if err != nil {
+		return "", err
+	}
+	return scrubbedHash, nil
+}
+
+// rewriteIndex replaces the index with the current entries.  The index is
+// written to a temporary file first so that it is never partially written.
+//
+// This function must be called with the archive mutex held.
+func (a *Archive) rewriteIndex() error {
+	endpoints := make([]string, 0, len(a.entries))
+	for endpoint := range a.entries {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+
+	var buf bytes.Buffer
+	for _, endpoint := range endpoints {
+		for _, entry := range a.entries[endpoint] {
+			b, err := json.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			buf.Write(b)
+			buf.WriteByte('\n')
+		}
+	}
+
+	path := filepath.Join(a.dir, archiveIndexFilename)
+	tmp := path + ".tmp"
+	err := ioutil.WriteFile(tmp, buf.Bytes(), 0600)
+	if err != nil {
+		return err
+	}
+	err = a.index.Close()
+	if err != nil {
+		return err
+	}
+
+	// Reopen the index even when it could not be replaced so that later
+	// entries are still recorded.
+	err = os.Rename(tmp, path)
+	index, openErr := a.openIndex()
+	if openErr == nil {
+		a.index = index
+	}
+	if err != nil {
+		return err
+	}
+	return openErr
+}
+
+// Latest returns the most recently fetched payload of the passed endpoint.
+// ErrNotArchived is returned when the endpoint was never archived.
+func (a *Archive) Latest(endpoint string) ([]byte, error) {
+	a.mtx.Lock()
+	entries := a.entries[endpoint]
+	a.mtx.Unlock()
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: %v", ErrNotArchived, endpoint)
+	}
+	latest := entries[0]
+	for _, entry := range entries[1:] {
+		if entry.Fetched >= latest.Fetched {
+			latest = entry
+		}
+	}
+
+	return a.Load(latest.Hash)
+}
+
+// Load returns the payload with the passed hash.
+func (a *Archive) Load(hash string) ([]byte, error) {
+	if len(hash) != sha256.Size*2 {
+		return nil, fmt.Errorf("invalid payload hash %q", hash)
+	}
+	f, err := os.Open(a.objectPath(hash))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(payload)
+	if hex.EncodeToString(sum[:]) != hash {
+		return nil, fmt.Errorf("archived payload %v is corrupt", hash)
+	}
+	return payload, nil
+}
+
+// Entries returns the archive entries of the passed endpoint ordered by the
+// time they were fetched.
+func (a *Archive) Entries(endpoint string) []ArchiveEntry {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	entries := make([]ArchiveEntry, len(a.entries[endpoint]))
+	copy(entries, a.entries[endpoint])
+	return entries
+}
+
+// Organizations returns the organizations whose repository listings were
+// archived.
+func (a *Archive) Organizations() []string {
+	prefix := apiOrgReposURL[:strings.Index(apiOrgReposURL, "%s")]
+	suffix := apiOrgReposURL[strings.Index(apiOrgReposURL, "%s")+2:]
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	var orgs []string
+	for endpoint := range a.entries {
+		if !strings.HasPrefix(endpoint, prefix) ||
+			!strings.HasSuffix(endpoint, suffix) {
+			continue
+		}
+		org := strings.TrimSuffix(strings.TrimPrefix(endpoint, prefix),
+			suffix)
+		orgs = append(orgs, org)
+	}
+	sort.Strings(orgs)
+	return orgs
+}
+
+// archiveTransport is a http.RoundTripper that archives the successful
+// responses of GET requests.
+type archiveTransport struct {
+	base    http.RoundTripper
+	archive *Archive
+}
+
+// RoundTrip satisfies the http.RoundTripper interface.
+func (t *archiveTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := req.URL.String()
+	if req.Method != http.MethodGet || res.StatusCode != http.StatusOK ||
+		endpoint == apiRateLimitURL {
+		return res, nil
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	// Failing to archive a response is not fatal to the sync.
+	err = t.archive.Store(endpoint, time.Now(), body)
+	if err != nil {
+		log.Warnf("Unable to archive %v: %v", endpoint, err)
+	}
+
+	return res, nil
+}
+
+// replayTransport is a http.RoundTripper that serves the most recently
+// archived response of every endpoint without any network access.
+type replayTransport struct {
+	archive *Archive
+}
+
+// RoundTrip satisfies the http.RoundTripper interface.
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return nil, fmt.Errorf("%v requests can not be replayed",
+			req.Method)
+	}
+	payload, err := t.archive.Latest(req.URL.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        make(http.Header),
+		Body:          ioutil.NopCloser(bytes.NewReader(payload)),
+		ContentLength: int64(len(payload)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Erasure identifies a user that is removed from, or pseudonymized in, the
+// archived responses.
+type Erasure struct {
+	UserIDs   []int64
+	Logins    []string
+	Emails    []string
+	Pseudonym string // Replaces the user, the user is purged when empty
+}
+
+// ownerKeys are the keys of the objects that identify the user an object
+// belongs to.  Objects that belong to a purged user are removed entirely.
+var ownerKeys = map[string]struct{}{
+	"user":   {},
+	"author": {},
+	"actor":  {},
+	"commit": {},
+}
+
+// erasureMatcher matches GitHub users and git authors in decoded JSON against
+// erasures.
+type erasureMatcher struct {
+	userIDs map[string]string // Pseudonyms by decimal account ID
+	logins  map[string]string
+	emails  map[string]string
+}
+
+// newErasureMatcher returns a matcher of the passed erasures.
+func newErasureMatcher(erasures []Erasure) *erasureMatcher {
+	m := &erasureMatcher{
+		userIDs: make(map[string]string),
+		logins:  make(map[string]string),
+		emails:  make(map[string]string),
+	}
+	for _, e := range erasures {
+		for _, id := range e.UserIDs {
+			if id != 0 {
+				m.userIDs[strconv.FormatInt(id, 10)] = e.Pseudonym
+			}
+		}
+		for _, login := range e.Logins {
+			if login != "" {
+				m.logins[strings.ToLower(login)] = e.Pseudonym
+			}
+		}
+		for _, email := range e.Emails {
+			if email != "" {
+				m.emails[strings.ToLower(email)] = e.Pseudonym
+			}
+		}
+	}
+	return m
+}
+
+// empty returns whether the matcher matches nothing.
+func (m *erasureMatcher) empty() bool {
+	return len(m.userIDs) == 0 && len(m.logins) == 0 && len(m.emails) == 0
+}
+
+// matchUser returns whether the passed object is a GitHub user of an erased
+// user along with the pseudonym that replaces it.
+func (m *erasureMatcher) matchUser(obj map[string]interface{}) (string, bool) {
+	login, ok := obj["login"].(string)
+	if !ok {
+		return "", false
+	}
+	id, ok := obj["id"].(json.Number)
+	if !ok {
+		return "", false
+	}
+	if pseudonym, ok := m.userIDs[id.String()]; ok {
+		return pseudonym, true
+	}
+	pseudonym, ok := m.logins[strings.ToLower(login)]
+	return pseudonym, ok
+}
+
+// matchGitUser returns whether the passed object is the git author or
+// committer of a commit by an erased user along with the pseudonym that
+// replaces it.
+func (m *erasureMatcher) matchGitUser(obj map[string]interface{}) (string, bool) {
+	email, ok := obj["email"].(string)
+	if !ok {
+		return "", false
+	}
+	if _, ok := obj["name"]; !ok {
+		return "", false
+	}
+	pseudonym, ok := m.emails[strings.ToLower(email)]
+	return pseudonym, ok
+}
+
+// scrub returns the passed decoded JSON value with all erased users removed or
+// pseudonymized, whether it was changed, and whether it belongs to a purged
+// user and must be removed entirely.
+//
+// GitHub users of purged users are removed from arrays and replaced by null
+// elsewhere, while the names and emails of their git authors are cleared.
+// Objects owned by a purged user, such as their pull requests, reviews and
+// commits, are removed as well.  Pseudonymized users are replaced by a user
+// with the pseudonym as login and a zero ID.
+func (m *erasureMatcher) scrub(v interface{}) (interface{}, bool, bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if pseudonym, ok := m.matchUser(v); ok {
+			if pseudonym == "" {
+				return nil, true, true
+			}
+			return map[string]interface{}{
+				"login": pseudonym,
+				"id":    json.Number("0"),
+				"type":  v["type"],
+			}, true, false
+		}
+		if pseudonym, ok := m.matchGitUser(v); ok {
+			obj := make(map[string]interface{}, len(v))
+			for k, value := range v {
+				obj[k] = value
+			}
+			obj["name"] = pseudonym
+			obj["email"] = ""
+			return obj, true, pseudonym == ""
+		}
+
+		changed := false
+		for k, value := range v {
+			scrubbed, c, purged := m.scrub(value)
+			if !c {
+				continue
+			}
+			if _, ok := ownerKeys[k]; ok && purged {
+				return nil, true, true
+			}
+			v[k] = scrubbed
+			changed = true
+		}
+		return v, changed, false
+
+	case []interface{}:
+		changed := false
+		elems := v[:0]
+		for _, elem := range v {
+			scrubbed, c, purged := m.scrub(elem)
+			changed = changed || c
+			if purged {
+				continue
+			}
+			elems = append(elems, scrubbed)
+		}
+		return elems, changed, false
+	}
+
+	return v, false, false
+}
+
+// scrubPayload returns the passed archived payload with all erased users
+// removed or pseudonymized, whether it was changed, and whether the payload
+// belongs to a purged user and must be removed entirely.  Payloads that are
+// not JSON are returned unchanged.
+func (m *erasureMatcher) scrubPayload(payload []byte) ([]byte, bool, bool, error) {
+	return rewritePayload(payload, m.scrub)
+}
+
+// rewritePayload decodes the passed archived payload, applies the passed
+// scrub function to it and returns the encoded result along with whether it
+// was changed and whether the payload must be removed entirely.  Payloads that
+// are not JSON are returned unchanged.
+func rewritePayload(payload []byte, scrub func(interface{}) (interface{}, bool, bool)) ([]byte, bool, bool, error) {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return payload, false, false, nil
+	}
+
+	scrubbed, changed, purged := scrub(v)
+	if purged {
+		return nil, true, true, nil
+	}
+	if !changed {
+		return payload, false, false, nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(scrubbed)
+	if err != nil {
+		return nil, false, false, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), true, false, nil
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "githubtracker",
+		Subsystem: "github",
+		Name:      "requests_total",
+		Help:      "GitHub API requests by endpoint and HTTP status.",
+	}, []string{"endpoint", "status"})
+
+	rateLimitRemaining = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "githubtracker",
+		Subsystem: "github",
+		Name:      "ratelimit_remaining",
+		Help:      "Remaining GitHub API requests of each rate limit bucket.",
+	}, []string{"bucket"})
+)
+
+// RegisterMetrics registers the Prometheus collectors of the package with r.
+func RegisterMetrics(r prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{requestsTotal, rateLimitRemaining} {
+		if err := r.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setRateLimitMetrics records the remaining requests of every bucket of a
+// rate_limit response.
+func setRateLimitMetrics(resources *ApiRateLimitResource) {
+	rateLimitRemaining.WithLabelValues("core").Set(float64(resources.Core.Remaining))
+	rateLimitRemaining.WithLabelValues("search").Set(float64(resources.Search.Remaining))
+	rateLimitRemaining.WithLabelValues("graphql").Set(float64(resources.GraphQL.Remaining))
+	rateLimitRemaining.WithLabelValues("integration_manifest").Set(
+		float64(resources.IntegrationManifest.Remaining))
+}
+
+// endpointLabel returns the path of a request URL with its owner, repository,
+// number and SHA segments replaced by placeholders, so that the endpoint label
+// only takes a bounded number of values.
+func endpointLabel(u *url.URL) string {
+	segs := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch {
+	case len(segs) >= 3 && segs[0] == "repos":
+		segs[1], segs[2] = "{owner}", "{repo}"
+		for i := 4; i < len(segs); i++ {
+			switch segs[i-1] {
+			case "pulls", "issues":
+				segs[i] = "{number}"
+			case "commits":
+				segs[i] = "{sha}"
+			}
+		}
+	case len(segs) >= 2 && (segs[0] == "users" || segs[0] == "orgs"):
+		segs[1] = "{owner}"
+	}
+	return "/" + strings.Join(segs, "/")
+}
+
+// metricsTransport is a http.RoundTripper that counts the requests made to
+// GitHub and records the rate limit headers of their responses.
+type metricsTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip satisfies the http.RoundTripper interface.
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := endpointLabel(req.URL)
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		requestsTotal.WithLabelValues(endpoint, "error").Inc()
+		return nil, err
+	}
+	requestsTotal.WithLabelValues(endpoint, strconv.Itoa(res.StatusCode)).Inc()
+
+	remaining, err := strconv.Atoi(res.Header.Get("X-RateLimit-Remaining"))
+	if err == nil {
+		bucket := res.Header.Get("X-RateLimit-Resource")
+		if bucket == "" {
+			bucket = "core"
+		}
+		rateLimitRemaining.WithLabelValues(bucket).Set(float64(remaining))
+	}
+
+	return res, nil
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestEndpointLabel(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{
+			url:  "https://api.github.com/repos/decred/dcrd/pulls?per_page=250&page=2&state=all",
+			want: "/repos/{owner}/{repo}/pulls",
+		},
+		{
+			url:  "https://api.github.com/repos/decred/dcrd/pulls/2000",
+			want: "/repos/{owner}/{repo}/pulls/{number}",
+		},
+		{
+			url:  "https://api.github.com/repos/decred/dcrd/pulls/2000/commits?per_page=250&page=1",
+			want: "/repos/{owner}/{repo}/pulls/{number}/commits",
+		},
+		{
+			url:  "https://api.github.com/repos/decred/dcrd/pulls/2000/reviews",
+			want: "/repos/{owner}/{repo}/pulls/{number}/reviews",
+		},
+		{
+			url:  "https://api.github.com/repos/decred/dcrd/issues/2000/timeline",
+			want: "/repos/{owner}/{repo}/issues/{number}/timeline",
+		},
+		{
+			url:  "https://api.github.com/repos/decred/dcrd/commits/0123456789abcdef",
+			want: "/repos/{owner}/{repo}/commits/{sha}",
+		},
+		{
+			url:  "https://api.github.com/repos/decred/dcrd/releases",
+			want: "/repos/{owner}/{repo}/releases",
+		},
+		{
+			url:  "https://api.github.com/users/decred/repos?per_page=250",
+			want: "/users/{owner}/repos",
+		},
+		{
+			url:  "https://api.github.com/orgs/decred",
+			want: "/orgs/{owner}",
+		},
+		{
+			url:  "https://api.github.com/rate_limit",
+			want: "/rate_limit",
+		},
+		{
+			url:  "https://api.github.com/repos/decred",
+			want: "/repos/decred",
+		},
+	}
+	for _, test := range tests {
+		u, err := url.Parse(test.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := endpointLabel(u)
+		if got != test.want {
+			t.Errorf("endpointLabel(%q): got %q, want %q", test.url, got,
+				test.want)
+		}
+	}
+}
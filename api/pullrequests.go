@@ -20,6 +20,8 @@ func (a *Client) FetchPullRequest(org, repo string, prNum int) (*ApiPullRequest,
 	if err != nil {
 		return nil, err
 	}
+	// The draft flag is only returned with the shadow-cat preview.
+	req.Header.Add("Accept", "application/vnd.github.shadow-cat-preview+json")
 	a.RateLimit()
 	res, err := a.gh.Do(req)
 	if err != nil {
@@ -6,13 +6,30 @@ import (
 	"time"
 )
 
+const (
+	apiRateLimitURL = `https://api.github.com/rate_limit`
+)
+
+// RateLimitState returns the last known core rate limit without consuming a
+// request.  It is zero until the first request is made.
+func (a *Client) RateLimitState() ApiRateLimitRule {
+	a.rateLimitMtx.Lock()
+	defer a.rateLimitMtx.Unlock()
+
+	return a.rateLimit
+}
+
 func (a *Client) RateLimit() (ApiRateLimitRule, error) {
 	defer a.rateLimitMtx.Unlock()
 	a.rateLimitMtx.Lock()
 
+	if a.offline {
+		return a.rateLimit, nil
+	}
+
 	for {
 		if a.rateLimit.Remaining == 0 {
-			b, err := a.gh.Get("https://api.github.com/rate_limit")
+			b, err := a.gh.Get(apiRateLimitURL)
 			if err != nil {
 				return ApiRateLimitRule{}, err
 			}
@@ -26,6 +43,7 @@ func (a *Client) RateLimit() (ApiRateLimitRule, error) {
 			if err != nil {
 				return ApiRateLimitRule{}, err
 			}
+			setRateLimitMetrics(&apiRateLimit.Resources)
 			core := apiRateLimit.Resources.Core
 			if core.Remaining == 0 {
 				exp := time.Unix(core.Reset, 0)
@@ -0,0 +1,84 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"strings"
+	"time"
+)
+
+// TrimCommitMessages drops the body of the message of every git commit
+// authored before the passed time from all archived payloads, keeping only
+// its subject line, so that reindexing does not restore the bodies dropped
+// from the database.  Trimmed payloads are stored under their new hash and the
+// index is rewritten.  Payloads that are no longer referenced are deleted.
+// The number of rewritten entries is returned.
+func (a *Archive) TrimCommitMessages(before time.Time) (int, error) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	return a.rewrite(func(payload []byte) ([]byte, bool, bool, error) {
+		return rewritePayload(payload,
+			func(v interface{}) (interface{}, bool, bool) {
+				changed := trimCommitMessages(v, before)
+				return v, changed, false
+			})
+	})
+}
+
+// trimCommitMessages trims the messages of the git commits authored before
+// the passed time in the passed decoded JSON value to their subject line in
+// place and returns whether any message was trimmed.  Git commits are the
+// objects with a message and an author carrying the authored date.
+func trimCommitMessages(v interface{}, before time.Time) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if trimCommitMessage(v, before) {
+			changed = true
+		}
+		for _, value := range v {
+			if trimCommitMessages(value, before) {
+				changed = true
+			}
+		}
+
+	case []interface{}:
+		for _, elem := range v {
+			if trimCommitMessages(elem, before) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// trimCommitMessage trims the message of the passed object to its subject
+// line when it is a git commit authored before the passed time and returns
+// whether the message was trimmed.
+func trimCommitMessage(obj map[string]interface{}, before time.Time) bool {
+	message, ok := obj["message"].(string)
+	if !ok {
+		return false
+	}
+	author, ok := obj["author"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	date, ok := author["date"].(string)
+	if !ok {
+		return false
+	}
+	authoredAt, err := time.Parse(time.RFC3339, date)
+	if err != nil || !authoredAt.Before(before) {
+		return false
+	}
+	i := strings.IndexByte(message, '\n')
+	if i < 0 {
+		return false
+	}
+	obj["message"] = message[:i]
+	return true
+}
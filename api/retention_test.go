@@ -0,0 +1,77 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTrimCommitMessages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	a, err := OpenArchive(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const endpoint = "https://api.github.com/repos/decred/dcrd/pulls/1/commits"
+	payload := `[{"sha":"a","commit":{"author":{"name":"Alice",` +
+		`"email":"alice@example.com","date":"2019-01-02T03:04:05Z"},` +
+		`"message":"multi: Fix\n\nLong body"}},` +
+		`{"sha":"b","commit":{"author":{"name":"Alice",` +
+		`"email":"alice@example.com","date":"2020-06-01T00:00:00Z"},` +
+		`"message":"multi: Keep\n\nRecent body"}}]`
+	err = a.Store(endpoint, time.Now(), []byte(payload))
+	if err != nil {
+		a.Close()
+		t.Fatal(err)
+	}
+
+	before := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	n, err := a.TrimCommitMessages(before)
+	a.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("got %d rewritten entries, want 1", n)
+	}
+
+	// The trimmed payload must also be found after reopening the index.
+	a, err = OpenArchive(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	got, err := a.Latest(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"commit":{"author":{"date":"2019-01-02T03:04:05Z",` +
+		`"email":"alice@example.com","name":"Alice"},` +
+		`"message":"multi: Fix"},"sha":"a"},` +
+		`{"commit":{"author":{"date":"2020-06-01T00:00:00Z",` +
+		`"email":"alice@example.com","name":"Alice"},` +
+		`"message":"multi: Keep\n\nRecent body"},"sha":"b"}]`
+	if string(got) != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+
+	// Trimming again changes nothing.
+	n, err = a.TrimCommitMessages(before)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Fatalf("got %d rewritten entries, want 0", n)
+	}
+}
@@ -60,17 +60,48 @@ type ApiPullsRequest struct {
 }
 
 type ApiPullRequest struct {
-	URL       string  `json:"url"`
-	Number    int     `json:"number"`
-	User      ApiUser `json:"user"`
-	UpdatedAt string  `json:"updated_at"`
-	ClosedAt  string  `json:"closed_at"`
-	MergedAt  string  `json:"merged_at"`
-	Merged    bool    `json:"merged"`
-	State     string  `json:"state"`
-	Additions int     `json:"additions"`
-	Deletions int     `json:"deletions"`
-	MergedBy  ApiUser `json:"merged_by"`
+	URL                string            `json:"url"`
+	Number             int               `json:"number"`
+	Title              string            `json:"title"`
+	Body               string            `json:"body"`
+	User               ApiUser           `json:"user"`
+	Labels             []ApiLabel        `json:"labels"`
+	Base               ApiPullRequestRef `json:"base"`
+	Head               ApiPullRequestRef `json:"head"`
+	Draft              bool              `json:"draft"`
+	CreatedAt          string            `json:"created_at"`
+	UpdatedAt          string            `json:"updated_at"`
+	ClosedAt           string            `json:"closed_at"`
+	MergedAt           string            `json:"merged_at"`
+	Merged             bool              `json:"merged"`
+	State              string            `json:"state"`
+	Additions          int               `json:"additions"`
+	Deletions          int               `json:"deletions"`
+	MergedBy           ApiUser           `json:"merged_by"`
+	RequestedReviewers []ApiUser         `json:"requested_reviewers"`
+	Milestone          *ApiMilestone     `json:"milestone"`
+}
+
+type ApiLabel struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+type ApiMilestone struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+}
+
+// ApiPullRequestRef is the base or head branch of a pull request.  Repo is nil
+// when the head repository has been deleted.
+type ApiPullRequestRef struct {
+	Label string         `json:"label"`
+	Ref   string         `json:"ref"`
+	SHA   string         `json:"sha"`
+	User  ApiUser        `json:"user"`
+	Repo  *ApiRepository `json:"repo"`
 }
 
 type ApiPullRequestCommit struct {
@@ -112,12 +143,16 @@ type ApiRelease struct {
 }
 
 type ApiRepository struct {
-	Name     string  `json:"name"`
-	FullName string  `json:"full_name"`
-	Private  bool    `json:"private"`
-	Owner    ApiUser `json:"owner"`
-	Fork     bool    `json:"fork"`
-	URL      string  `json:"url"`
+	ID            int64   `json:"id"`
+	NodeID        string  `json:"node_id"`
+	Name          string  `json:"name"`
+	FullName      string  `json:"full_name"`
+	Private       bool    `json:"private"`
+	Owner         ApiUser `json:"owner"`
+	Fork          bool    `json:"fork"`
+	Archived      bool    `json:"archived"`
+	DefaultBranch string  `json:"default_branch"`
+	URL           string  `json:"url"`
 }
 
 type ApiPullRequestReview struct {
@@ -102,6 +102,7 @@ type config struct {
 	ConfigFile      string `short:"C" long:"configfile" description:"Path to configuration file"`
 	RPCUser         string `short:"u" long:"rpcuser" description:"RPC username"`
 	RPCPassword     string `short:"P" long:"rpcpass" default-mask:"-" description:"RPC password"`
+	APIKey          string `long:"apikey" default-mask:"-" description:"RPC API key (overrides rpcuser and rpcpass)"`
 	RPCServer       string `short:"s" long:"rpcserver" description:"RPC server to connect to"`
 	WalletRPCServer string `short:"w" long:"walletrpcserver" description:"Wallet RPC server to connect to"`
 	RPCCert         string `short:"c" long:"rpccert" description:"RPC server certificate chain for validation"`
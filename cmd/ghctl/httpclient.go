@@ -93,8 +93,12 @@ func sendPostRequest(marshalledJSON []byte, cfg *config) ([]byte, error) {
 	httpRequest.Close = true
 	httpRequest.Header.Set("Content-Type", "application/json")
 
-	// Configure basic access authorization.
-	httpRequest.SetBasicAuth(cfg.RPCUser, cfg.RPCPassword)
+	// Configure bearer or basic access authorization.
+	if cfg.APIKey != "" {
+		httpRequest.Header.Set("Authorization", "Bearer "+cfg.APIKey)
+	} else {
+		httpRequest.SetBasicAuth(cfg.RPCUser, cfg.RPCPassword)
+	}
 
 	// Create the new HTTP client that is configured according to the user-
 	// specified options and submit the request.
@@ -30,6 +30,8 @@ const (
 	defaultLogFilename    = "github-tracker.log"
 	defaultRPCPort        = "8001"
 	defaultLogDirname     = "logs"
+	defaultArchiveDirname = "archive"
+	defaultStopTimeout    = time.Minute
 )
 
 var (
@@ -42,23 +44,42 @@ var (
 )
 
 type config struct {
-	ConfigFile          string          `short:"C" long:"configfile" description:"Path to configuration file"`
-	DataDir             string          `short:"b" long:"datadir" description:"Directory to store data"`
-	APIToken            string          `long:"apitoken" description:"github api token"`
-	Update              bool            `long:"update" description:"fetch latest github data"`
-	RPCCert             *ExplicitString `long:"rpccert" description:"RPC server TLS certificate"`
-	RPCKey              *ExplicitString `long:"rpckey" description:"RPC server TLS key"`
-	TLSCurve            *CurveFlag      `long:"tlscurve" description:"Curve to use when generating TLS keypairs"`
-	LegacyRPCListeners  []string        `long:"rpclisten" description:"Listen for JSON-RPC connections on this interface"`
-	LegacyRPCMaxClients int64           `long:"rpcmaxclients" description:"Max JSON-RPC HTTP POST clients"`
-	RPCUsername         string          `long:"rpcuser" description:"JSON-RPC username"`
-	RPCPassword         string          `long:"rpcpass" default-mask:"-" description:"JSON-RPC password"`
-	LogDir              *ExplicitString `long:"logdir" description:"Directory to log output."`
-	DebugLevel          string          `short:"d" long:"debuglevel" description:"Logging level {trace, debug, info, warn, error, critical}"`
-	DBHost              string          `long:"dbhost" description:"Database ip:port"`
-	DBRootCert          string          `long:"dbrootcert" description:"File containing the CA certificate for the database"`
-	DBCert              string          `long:"dbcert" description:"File containing the politeiawww client certificate for the database"`
-	DBKey               string          `long:"dbkey" description:"File containing the politeiawww client certificate key for the database"`
+	ConfigFile             string          `short:"C" long:"configfile" description:"Path to configuration file"`
+	DataDir                string          `short:"b" long:"datadir" description:"Directory to store data"`
+	APIToken               string          `long:"apitoken" description:"github api token"`
+	Update                 bool            `long:"update" description:"fetch latest github data"`
+	ArchiveDir             string          `long:"archivedir" description:"Directory to archive raw github api responses in (default: datadir/archive)"`
+	NoArchive              bool            `long:"noarchive" description:"Disable archiving of raw github api responses"`
+	Build                  bool            `long:"build" description:"Build the database when it has no or an outdated version record, dropping the data fetched from github"`
+	Reindex                bool            `long:"reindex" description:"Rebuild the database from the api archive without network access and exit"`
+	Export                 string          `long:"export" description:"Export the database to the file as JSON lines and exit"`
+	Import                 string          `long:"import" description:"Import a database export from the file and exit"`
+	CommitRetention        int             `long:"commitretention" description:"Months after which commit message bodies are dropped (0 keeps them forever)"`
+	RPCCert                *ExplicitString `long:"rpccert" description:"RPC server TLS certificate"`
+	RPCKey                 *ExplicitString `long:"rpckey" description:"RPC server TLS key"`
+	TLSCurve               *CurveFlag      `long:"tlscurve" description:"Curve to use when generating TLS keypairs"`
+	GRPCListeners          []string        `long:"grpclisten" description:"Listen for gRPC connections on this interface"`
+	LegacyRPCListeners     []string        `long:"rpclisten" description:"Listen for JSON-RPC connections on this interface"`
+	LegacyRPCMaxClients    int64           `long:"rpcmaxclients" description:"Max JSON-RPC HTTP POST clients"`
+	LegacyRPCMaxWebsockets int64           `long:"rpcmaxwebsockets" description:"Max JSON-RPC websocket clients"`
+	MetricsListeners       []string        `long:"metricslisten" description:"Serve Prometheus metrics at /metrics over plain HTTP on this interface (disabled by default)"`
+	StopTimeout            time.Duration   `long:"stoptimeout" description:"Time the stop RPC waits for running syncs to finish their current repository before shutting down"`
+	RPCUsername            string          `long:"rpcuser" description:"JSON-RPC username"`
+	RPCPassword            string          `long:"rpcpass" default-mask:"-" description:"JSON-RPC password"`
+	RPCUsersFile           string          `long:"rpcusersfile" description:"File of additional JSON-RPC users, one 'username password role' per line (roles: reader, operator, admin)"`
+	RPCClientCA            string          `long:"rpcclientca" description:"CA bundle that JSON-RPC client certificates must be signed by (enables client certificate authentication)"`
+	RPCClientCerts         []string        `long:"rpcclientcert" description:"Grant a role to the JSON-RPC client certificates with the subject common name or SAN, as name:role (may be repeated)"`
+	LogDir                 *ExplicitString `long:"logdir" description:"Directory to log output."`
+	DebugLevel             string          `short:"d" long:"debuglevel" description:"Logging level {trace, debug, info, warn, error, critical}"`
+	DBDSN                  string          `long:"dbdsn" default-mask:"-" description:"Full database connection string (overrides all other db options)"`
+	DBHost                 string          `long:"dbhost" description:"Database ip:port"`
+	DBUser                 string          `long:"dbuser" description:"Database user (default: githubtracker)"`
+	DBPass                 string          `long:"dbpass" default-mask:"-" description:"Database password (enables password authentication)"`
+	DBName                 string          `long:"dbname" description:"Database name (default: ght)"`
+	DBSSLMode              string          `long:"dbsslmode" description:"Database SSL mode {disable, require, verify-ca, verify-full}"`
+	DBRootCert             string          `long:"dbrootcert" description:"File containing the CA certificate for the database"`
+	DBCert                 string          `long:"dbcert" description:"File containing the politeiawww client certificate for the database"`
+	DBKey                  string          `long:"dbkey" description:"File containing the politeiawww client certificate key for the database"`
 }
 
 // validLogLevel returns whether or not logLevel is a valid debug log level.
@@ -83,14 +104,16 @@ func supportedSubsystems() []string {
 
 func loadConfig() (*config, error) {
 	cfg := config{
-		ConfigFile:          defaultConfigFile,
-		DataDir:             defaultAppDataDir,
-		APIToken:            defaultAPIToken,
-		RPCKey:              NewExplicitString(defaultRPCKeyFile),
-		RPCCert:             NewExplicitString(defaultRPCCertFile),
-		LogDir:              NewExplicitString(defaultLogDir),
-		TLSCurve:            NewCurveFlag(PreferredCurve),
-		LegacyRPCMaxClients: 5,
+		ConfigFile:             defaultConfigFile,
+		DataDir:                defaultAppDataDir,
+		APIToken:               defaultAPIToken,
+		RPCKey:                 NewExplicitString(defaultRPCKeyFile),
+		RPCCert:                NewExplicitString(defaultRPCCertFile),
+		LogDir:                 NewExplicitString(defaultLogDir),
+		TLSCurve:               NewCurveFlag(PreferredCurve),
+		LegacyRPCMaxClients:    5,
+		LegacyRPCMaxWebsockets: 25,
+		StopTimeout:            defaultStopTimeout,
 	}
 
 	appName := filepath.Base(os.Args[0])
@@ -135,6 +158,14 @@ func loadConfig() (*config, error) {
 
 	cfg.RPCCert.Value = cleanAndExpandPath(cfg.RPCCert.Value)
 	cfg.RPCKey.Value = cleanAndExpandPath(cfg.RPCKey.Value)
+	if cfg.RPCUsersFile != "" {
+		cfg.RPCUsersFile = cleanAndExpandPath(cfg.RPCUsersFile)
+	}
+	if cfg.RPCClientCA != "" {
+		cfg.RPCClientCA = cleanAndExpandPath(cfg.RPCClientCA)
+	} else if len(cfg.RPCClientCerts) != 0 {
+		return nil, fmt.Errorf("rpcclientcert requires rpcclientca")
+	}
 
 	// Default to localhost listen addresses if no listeners were manually
 	// specified.  When the RPC server is configured to be disabled, remove all
@@ -163,15 +194,71 @@ func loadConfig() (*config, error) {
 	*/
 	// Validate cache options.
 
+	err = validateDBOptions(&cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.DataDir = cleanAndExpandPath(cfg.DataDir)
+
+	// Only a single offline database command may be run at once.
+	commands := 0
+	for _, set := range []bool{cfg.Reindex, cfg.Export != "", cfg.Import != ""} {
+		if set {
+			commands++
+		}
+	}
+	if commands > 1 {
+		return nil, fmt.Errorf("only one of reindex, export and import " +
+			"may be specified")
+	}
+	cfg.Export = cleanAndExpandPath(cfg.Export)
+	cfg.Import = cleanAndExpandPath(cfg.Import)
+
+	// Archive raw api responses in the data directory by default.
+	if cfg.NoArchive && cfg.Reindex {
+		return nil, fmt.Errorf("reindex requires the api archive")
+	}
+	if cfg.ArchiveDir == "" {
+		cfg.ArchiveDir = filepath.Join(cfg.DataDir, defaultArchiveDirname)
+	}
+	cfg.ArchiveDir = cleanAndExpandPath(cfg.ArchiveDir)
+
+	if cfg.CommitRetention < 0 {
+		return nil, fmt.Errorf("commitretention may not be negative")
+	}
+
+	return &cfg, nil
+}
+
+// validateDBOptions validates the database connection options.  A full DSN
+// is passed to the database driver as is.  Otherwise a host is required along
+// with either a password or the client certificates used by CockroachDB
+// certificate authentication.
+func validateDBOptions(cfg *config) error {
+	if cfg.DBDSN != "" {
+		return nil
+	}
+
 	switch {
 	case cfg.DBHost == "":
-		return nil, fmt.Errorf("dbhost param is required")
+		return fmt.Errorf("dbhost param is required")
+	case cfg.DBPass != "":
+		// Password authentication does not require client
+		// certificates.
 	case cfg.DBRootCert == "":
-		return nil, fmt.Errorf("dbrootcert param is required")
+		return fmt.Errorf("dbrootcert param is required")
 	case cfg.DBCert == "":
-		return nil, fmt.Errorf("dbcert param is required")
+		return fmt.Errorf("dbcert param is required")
 	case cfg.DBKey == "":
-		return nil, fmt.Errorf("dbkey param is required")
+		return fmt.Errorf("dbkey param is required")
+	}
+
+	switch cfg.DBSSLMode {
+	case "", "disable", "allow", "prefer", "require", "verify-ca",
+		"verify-full":
+	default:
+		return fmt.Errorf("invalid dbsslmode %q", cfg.DBSSLMode)
 	}
 
 	cfg.DBRootCert = cleanAndExpandPath(cfg.DBRootCert)
@@ -179,31 +266,37 @@ func loadConfig() (*config, error) {
 	cfg.DBKey = cleanAndExpandPath(cfg.DBKey)
 
 	// Validate db host.
-	_, err = url.Parse(cfg.DBHost)
+	_, err := url.Parse(cfg.DBHost)
 	if err != nil {
-		return nil, fmt.Errorf("parse dbhost: %v", err)
+		return fmt.Errorf("parse dbhost: %v", err)
 	}
 
 	// Validate db root cert.
-	b, err := ioutil.ReadFile(cfg.DBRootCert)
-	if err != nil {
-		return nil, fmt.Errorf("read dbrootcert: %v", err)
-	}
-	block, _ := pem.Decode(b)
-	_, err = x509.ParseCertificate(block.Bytes)
-	if err != nil {
-		return nil, fmt.Errorf("parse dbrootcert: %v", err)
+	if cfg.DBRootCert != "" {
+		b, err := ioutil.ReadFile(cfg.DBRootCert)
+		if err != nil {
+			return fmt.Errorf("read dbrootcert: %v", err)
+		}
+		block, _ := pem.Decode(b)
+		if block == nil {
+			return fmt.Errorf("parse dbrootcert: no PEM data found")
+		}
+		_, err = x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("parse dbrootcert: %v", err)
+		}
 	}
 
 	// Validate db key pair.
-	_, err = tls.LoadX509KeyPair(cfg.DBCert, cfg.DBKey)
-	if err != nil {
-		return nil, fmt.Errorf("load key pair dbcert "+
-			"and dbkey: %v", err)
+	if cfg.DBCert != "" || cfg.DBKey != "" {
+		_, err = tls.LoadX509KeyPair(cfg.DBCert, cfg.DBKey)
+		if err != nil {
+			return fmt.Errorf("load key pair dbcert "+
+				"and dbkey: %v", err)
+		}
 	}
 
-	cfg.DataDir = cleanAndExpandPath(cfg.DataDir)
-	return &cfg, nil
+	return nil
 }
 
 // cleanAndExpandPath expands environement variables and leading ~ in the
@@ -0,0 +1,131 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package cockroachdb
+
+import (
+	"context"
+
+	"github.com/decred/github-tracker/database"
+	"github.com/jinzhu/gorm"
+)
+
+// NewAlias assigns a login or email to a contributor.  ErrAliasExists is
+// returned when the alias is already assigned to any contributor.
+//
+// NewAlias satisfies the database interface.
+func (c *cockroachdb) NewAlias(ctx context.Context, dbAlias *database.Alias) error {
+	alias := EncodeAlias(dbAlias)
+
+	log.Debugf("NewAlias: %v %v %v", alias.Contributor, alias.Type,
+		alias.Value)
+
+	tx := c.beginTx(ctx)
+	var existing Alias
+	err := tx.
+		Where("type = ? AND value = ?", alias.Type, alias.Value).
+		Find(&existing).
+		Error
+	switch {
+	case err == nil:
+		tx.Rollback()
+		return database.ErrAliasExists
+	case err != gorm.ErrRecordNotFound:
+		tx.Rollback()
+		return err
+	}
+	err = tx.Create(&alias).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// DeleteAlias removes the alias with the passed type and value.
+//
+// DeleteAlias satisfies the database interface.
+func (c *cockroachdb) DeleteAlias(ctx context.Context, aliasType, value string) error {
+	log.Debugf("DeleteAlias: %v %v", aliasType, value)
+
+	tx := c.beginTx(ctx)
+	res := tx.
+		Where("type = ? AND value = ?", aliasType, value).
+		Delete(Alias{})
+	if res.Error != nil {
+		tx.Rollback()
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		tx.Rollback()
+		return database.ErrAliasNotFound
+	}
+	return tx.Commit().Error
+}
+
+// AliasesByContributor returns the aliases of the passed contributor.
+//
+// AliasesByContributor satisfies the database interface.
+func (c *cockroachdb) AliasesByContributor(ctx context.Context, contributor string) ([]database.Alias, error) {
+	log.Debugf("AliasesByContributor: %v", contributor)
+
+	tx := c.beginTx(ctx)
+	defer tx.Rollback()
+
+	return findAliases(tx.Where("contributor = ?", contributor))
+}
+
+// Aliases returns all aliases ordered by contributor.
+//
+// Aliases satisfies the database interface.
+func (c *cockroachdb) Aliases(ctx context.Context) ([]database.Alias, error) {
+	log.Debugf("Aliases")
+
+	tx := c.beginTx(ctx)
+	defer tx.Rollback()
+
+	return findAliases(tx)
+}
+
+// findAliases returns the aliases matched by the passed query.
+func findAliases(query *gorm.DB) ([]database.Alias, error) {
+	aliases := make([]Alias, 0, 64)
+	err := query.
+		Order("contributor, type, value").
+		Find(&aliases).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	dbAliases := make([]database.Alias, 0, len(aliases))
+	for _, vv := range aliases {
+		dbAliases = append(dbAliases, DecodeAlias(&vv))
+	}
+	return dbAliases, nil
+}
+
+// ContributorByAlias returns the contributor the alias with the passed type
+// and value is assigned to.
+//
+// ContributorByAlias satisfies the database interface.
+func (c *cockroachdb) ContributorByAlias(ctx context.Context, aliasType, value string) (string, error) {
+	log.Debugf("ContributorByAlias: %v %v", aliasType, value)
+
+	tx := c.beginTx(ctx)
+	defer tx.Rollback()
+
+	var alias Alias
+	err := tx.
+		Where("type = ? AND value = ?", aliasType, value).
+		Find(&alias).
+		Error
+	if err == gorm.ErrRecordNotFound {
+		return "", database.ErrAliasNotFound
+	} else if err != nil {
+		return "", err
+	}
+	return alias.Contributor, nil
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package cockroachdb
+
+import (
+	"context"
+
+	"github.com/decred/github-tracker/database"
+	"github.com/jinzhu/gorm"
+)
+
+// NewAPIKey stores a new API key.
+//
+// NewAPIKey satisfies the database interface.
+func (c *cockroachdb) NewAPIKey(ctx context.Context, dbKey *database.APIKey) error {
+	key := EncodeAPIKey(dbKey)
+
+	log.Debugf("NewAPIKey: %v %v %v", key.ID, key.Name, key.Scope)
+
+	tx := c.beginTx(ctx)
+	err := tx.Create(&key).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// DeleteAPIKey removes the API key with the passed ID.
+//
+// DeleteAPIKey satisfies the database interface.
+func (c *cockroachdb) DeleteAPIKey(ctx context.Context, id string) error {
+	log.Debugf("DeleteAPIKey: %v", id)
+
+	tx := c.beginTx(ctx)
+	res := tx.
+		Where("id = ?", id).
+		Delete(APIKey{})
+	if res.Error != nil {
+		tx.Rollback()
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		tx.Rollback()
+		return database.ErrAPIKeyNotFound
+	}
+	return tx.Commit().Error
+}
+
+// APIKeyUsed records the passed UNIX timestamp as the time the API key with
+// the passed ID was last used.
+//
+// APIKeyUsed satisfies the database interface.
+func (c *cockroachdb) APIKeyUsed(ctx context.Context, id string, usedAt int64) error {
+	tx := c.beginTx(ctx)
+	err := tx.
+		Model(&APIKey{}).
+		Where("id = ?", id).
+		Update("last_used_at", usedAt).
+		Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// APIKeyByHash returns the API key with the passed hash.
+//
+// APIKeyByHash satisfies the database interface.
+func (c *cockroachdb) APIKeyByHash(ctx context.Context, hash string) (*database.APIKey, error) {
+	tx := c.beginTx(ctx)
+	defer tx.Rollback()
+
+	var key APIKey
+	err := tx.
+		Where("hash = ?", hash).
+		Find(&key).
+		Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, database.ErrAPIKeyNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return DecodeAPIKey(&key), nil
+}
+
+// APIKeys returns all API keys ordered by their creation time.
+//
+// APIKeys satisfies the database interface.
+func (c *cockroachdb) APIKeys(ctx context.Context) ([]database.APIKey, error) {
+	log.Debugf("APIKeys")
+
+	tx := c.beginTx(ctx)
+	defer tx.Rollback()
+
+	var keys []APIKey
+	err := tx.
+		Order("created_at, id").
+		Find(&keys).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	dbKeys := make([]database.APIKey, 0, len(keys))
+	for i := range keys {
+		dbKeys = append(dbKeys, *DecodeAPIKey(&keys[i]))
+	}
+	return dbKeys, nil
+}
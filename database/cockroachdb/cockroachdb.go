@@ -5,10 +5,10 @@
 package cockroachdb
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"path/filepath"
-	"strings"
 	"sync"
 	"time"
 
@@ -19,14 +19,26 @@ import (
 
 const (
 	cacheID   = "ght"
-	ghVersion = "1"
+	ghVersion = "9"
 
 	// Database table names
-	tableNameVersions     = "versions"
-	tableNameOrganization = "organizations"
-	tableNamePullRequest  = "pullrequests"
-	tableNameCommits      = "commits"
-	tableNameReviews      = "reviews"
+	tableNameVersions           = "versions"
+	tableNameOrganization       = "organizations"
+	tableNameRepositories       = "repositories"
+	tableNameRepositoryNames    = "repositorynames"
+	tableNamePullRequest        = "pullrequests"
+	tableNameCommits            = "commits"
+	tableNameReviews            = "reviews"
+	tableNameLabels             = "labels"
+	tableNameRequestedReviewers = "requestedreviewers"
+	tableNameUsers              = "users"
+	tableNameUserLogins         = "userlogins"
+	tableNameAliases            = "aliases"
+	tableNameChanges            = "changes"
+	tableNameSnapshots          = "snapshots"
+	tableNameRollups            = "rollups"
+	tableNameAPIKeys            = "apikeys"
+	tableNameErasedIdentities   = "erasedidentities"
 
 	userGithubTracker = "githubtracker" // cmsdb user (read/write access)
 )
@@ -38,34 +50,248 @@ type cockroachdb struct {
 	recordsdb *gorm.DB // Database context
 }
 
+// beginTx begins a transaction bound to the passed context.  Canceling the
+// context rolls the transaction back, so that no further queries of a
+// canceled request are run.  gorm does not pass the context to the statements
+// it runs, so a statement that is already running is not interrupted and the
+// rollback waits for it to complete.
+func (c *cockroachdb) beginTx(ctx context.Context) *gorm.DB {
+	return c.recordsdb.BeginTx(ctx, nil)
+}
+
 // Create new invoice.
 //
 // NewPullRequest satisfies the database interface.
-func (c *cockroachdb) NewPullRequest(dbPullRequest *database.PullRequest) error {
+func (c *cockroachdb) NewPullRequest(ctx context.Context, dbPullRequest *database.PullRequest) error {
 	pr := EncodePullRequest(dbPullRequest)
 
 	log.Debugf("NewPullRequest: %v", pr.URL)
-	return c.recordsdb.Create(&pr).Error
+
+	tx := c.beginTx(ctx)
+	err := tx.Create(&pr).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
 }
 
 // Update existing pr.
 //
 // UpdatePullRequest satisfies the database interface.
-func (c *cockroachdb) UpdatePullRequest(dbPullRequest *database.PullRequest) error {
+func (c *cockroachdb) UpdatePullRequest(ctx context.Context, dbPullRequest *database.PullRequest) error {
 	pr := EncodePullRequest(dbPullRequest)
 
 	log.Debugf("UpdatePullRequest: %v", pr.URL)
-	return c.recordsdb.Save(&pr).Error
+
+	tx := c.beginTx(ctx)
+	err := tx.Save(&pr).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// Create or replace a pull request along with its commits and reviews.  Erased
+// users are scrubbed from the passed pull request in place before it is
+// stored, and ErrUserErased is returned without storing anything when it was
+// authored by a purged user.
+//
+// UpsertPullRequest satisfies the database interface.
+func (c *cockroachdb) UpsertPullRequest(ctx context.Context, dbPullRequest *database.PullRequest) error {
+	log.Debugf("UpsertPullRequest: %v", dbPullRequest.URL)
+
+	tx := c.beginTx(ctx)
+	erasures, err := pullRequestErasures(tx, dbPullRequest)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = erasures.ScrubPullRequest(dbPullRequest)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	pr := EncodePullRequest(dbPullRequest)
+	users := make([]User, 0, len(dbPullRequest.Users))
+	for _, dbUser := range dbPullRequest.Users {
+		users = append(users, EncodeUser(&dbUser))
+	}
+
+	err = upsertUsers(tx, users, time.Now().Unix())
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = upsertPullRequest(tx, &pr)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// upsertPullRequest creates or updates the pull request and replaces all of
+// its commits and reviews.  Commits and reviews that are no longer present,
+// such as force-pushed away commits or dismissed reviews, are removed.
+//
+// This function must be called within a transaction.
+func upsertPullRequest(tx *gorm.DB, pr *PullRequest) error {
+	// Record the changes against the stored pull request and update the
+	// rollups by the difference between both versions before it is
+	// replaced.
+	old, err := storedPullRequest(tx, pr)
+	if err != nil {
+		return err
+	}
+	err = recordChanges(tx, old, pr, time.Now().Unix())
+	if err != nil {
+		return err
+	}
+	err = updateRollups(tx, old, pr)
+	if err != nil {
+		return err
+	}
+
+	// Save the pull request without its associations so that the child
+	// rows can be inserted explicitly.  The pull request is saved first
+	// since its URL changes when the repository is renamed, which cascades
+	// to the existing child rows.
+	parent := *pr
+	parent.Commits = nil
+	parent.Reviews = nil
+	parent.Labels = nil
+	parent.RequestedReviewers = nil
+	err = tx.Save(&parent).Error
+	if err != nil {
+		return err
+	}
+
+	// Remove the existing child rows.  Reviews are also removed by ID
+	// since review IDs are globally unique and rows may exist that were
+	// written without the pull request URL.
+	for _, model := range []interface{}{Commit{}, PullRequestReview{},
+		Label{}, RequestedReviewer{}} {
+		err = tx.
+			Where("pull_request_url = ?", pr.URL).
+			Delete(model).
+			Error
+		if err != nil {
+			return err
+		}
+	}
+	reviewIDs := make([]int64, 0, len(pr.Reviews))
+	for _, review := range pr.Reviews {
+		reviewIDs = append(reviewIDs, review.ID)
+	}
+	if len(reviewIDs) > 0 {
+		err = tx.
+			Where("id IN (?)", reviewIDs).
+			Delete(PullRequestReview{}).
+			Error
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, commit := range pr.Commits {
+		commit.PullRequestURL = pr.URL
+		err = tx.Create(&commit).Error
+		if err != nil {
+			return err
+		}
+	}
+	for _, review := range pr.Reviews {
+		review.PullRequestURL = pr.URL
+		err = tx.Create(&review).Error
+		if err != nil {
+			return err
+		}
+	}
+	for _, label := range pr.Labels {
+		label.PullRequestURL = pr.URL
+		err = tx.Create(&label).Error
+		if err != nil {
+			return err
+		}
+	}
+	for _, reviewer := range pr.RequestedReviewers {
+		reviewer.PullRequestURL = pr.URL
+		err = tx.Create(&reviewer).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// upsertUsers records the passed users and the logins they were seen with.
+// A login that differs from the stored one means that the account was renamed,
+// in which case the new login is added to the login history of the user.
+//
+// This function must be called within a transaction.
+func upsertUsers(tx *gorm.DB, users []User, seen int64) error {
+	for _, user := range users {
+		user.LastSeen = seen
+		err := tx.Save(&user).Error
+		if err != nil {
+			return err
+		}
+		err = tx.
+			Where(UserLogin{UserID: user.ID, Login: user.Login}).
+			Attrs(UserLogin{FirstSeen: seen}).
+			Assign(UserLogin{LastSeen: seen}).
+			FirstOrCreate(&UserLogin{}).
+			Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // PullRequestByURL Return invoice by its token.
-func (c *cockroachdb) PullRequestByURL(url string) (*database.PullRequest, error) {
+func (c *cockroachdb) PullRequestByURL(ctx context.Context, url string) (*database.PullRequest, error) {
 	log.Debugf("PullRequestByURL: %v", url)
 
-	pr := PullRequest{
-		URL: url,
+	tx := c.beginTx(ctx)
+	defer tx.Rollback()
+
+	var pr PullRequest
+	err := tx.
+		Preload("Labels").
+		Preload("RequestedReviewers").
+		Where("url = ?", url).
+		Find(&pr).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = database.ErrNoPullRequestFound
+		}
+		return nil, err
 	}
-	err := c.recordsdb.
+
+	return DecodePullRequest(&pr), nil
+}
+
+// PullRequestByNumber returns the pull request with the passed number in the
+// repository with the passed ID.
+//
+// PullRequestByNumber satisfies the database interface.
+func (c *cockroachdb) PullRequestByNumber(ctx context.Context, repoID int64, number int) (*database.PullRequest, error) {
+	log.Debugf("PullRequestByNumber: %v %v", repoID, number)
+
+	tx := c.beginTx(ctx)
+	defer tx.Rollback()
+
+	var pr PullRequest
+	err := tx.
+		Preload("Labels").
+		Preload("RequestedReviewers").
+		Where("repo_id = ? AND number = ?", repoID, number).
 		Find(&pr).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -77,18 +303,32 @@ func (c *cockroachdb) PullRequestByURL(url string) (*database.PullRequest, error
 	return DecodePullRequest(&pr), nil
 }
 
-func (c *cockroachdb) PullRequestsByUserDates(username string, start, end int64) ([]*database.PullRequest, error) {
-	log.Debugf("PullRequestsByUserDates: %v %v", time.Unix(start, 0),
-		time.Unix(end, 0))
+// PullRequestsByUserDates returns the pull requests of the passed
+// organization, or of all organizations when empty, authored by any of the
+// passed logins and merged from start up to but excluding end.
+//
+// PullRequestsByUserDates satisfies the database interface.
+func (c *cockroachdb) PullRequestsByUserDates(ctx context.Context, org string, usernames []string, start, end int64) ([]*database.PullRequest, error) {
+	log.Debugf("PullRequestsByUserDates: %v %v %v", org,
+		time.Unix(start, 0), time.Unix(end, 0))
+
+	tx := c.beginTx(ctx)
+	defer tx.Rollback()
 
 	// Get all PRs from a user between the given dates.
-	prs := make([]PullRequest, 0, 1024) // PNOOMA
-	err := c.recordsdb.
-		Where("author = ? AND "+
-			"merged_at BETWEEN ? AND ?",
-			username,
+	query := tx.
+		Preload("Labels").
+		Preload("RequestedReviewers").
+		Where("author IN (?) AND "+
+			"merged_at >= ? AND merged_at < ?",
+			usernames,
 			start,
-			end).
+			end)
+	if org != "" {
+		query = query.Where("LOWER(organization) = LOWER(?)", org)
+	}
+	prs := make([]PullRequest, 0, 1024) // PNOOMA
+	err := query.
 		Find(&prs).
 		Error
 	if err != nil {
@@ -101,118 +341,393 @@ func (c *cockroachdb) PullRequestsByUserDates(username string, start, end int64)
 	return dbPRs, nil
 }
 
-func (c *cockroachdb) ReviewsByUserDates(username string, start, end int64) ([]database.PullRequestReview, error) {
-	log.Debugf("ReviewsByUserDates: %v %v", time.Unix(start, 0),
+// ReviewsByUserDates returns the reviews on pull requests of the passed
+// organization, or of all organizations when empty, submitted by any of the
+// passed logins from start up to but excluding end.
+//
+// ReviewsByUserDates satisfies the database interface.
+func (c *cockroachdb) ReviewsByUserDates(ctx context.Context, org string, usernames []string, start, end int64) ([]database.PullRequestReview, error) {
+	log.Debugf("ReviewsByUserDates: %v %v %v", org, time.Unix(start, 0),
 		time.Unix(end, 0))
 
-	// Get all Reviews from a user between the given dates.
-	reviews := make([]PullRequestReview, 0, 1024) // PNOOMA
-	err := c.recordsdb.
+	tx := c.beginTx(ctx)
+	defer tx.Rollback()
+
+	// Get all reviews from a user between the given dates along with the
+	// repository and line counts of the pull request they belong to.
+	type reviewRow struct {
+		PullRequestReview
+		PRRepo      string
+		PRAdditions int
+		PRDeletions int
+	}
+	query := tx.
 		Table(tableNameReviews).
-		Where("author = ? AND "+
-			"submitted_at BETWEEN ? AND ?",
-			username,
+		Select(tableNameReviews+".*, "+
+			tableNamePullRequest+".repo AS pr_repo, "+
+			tableNamePullRequest+".additions AS pr_additions, "+
+			tableNamePullRequest+".deletions AS pr_deletions").
+		Joins("JOIN "+tableNamePullRequest+" ON "+
+			tableNamePullRequest+".url = "+
+			tableNameReviews+".pull_request_url").
+		Where(tableNameReviews+".author IN (?) AND "+
+			tableNameReviews+".submitted_at >= ? AND "+
+			tableNameReviews+".submitted_at < ?",
+			usernames,
 			start,
-			end).
-		Find(&reviews).
+			end)
+	if org != "" {
+		query = query.Where("LOWER("+tableNamePullRequest+
+			".organization) = LOWER(?)", org)
+	}
+	rows := make([]reviewRow, 0, 1024) // PNOOMA
+	err := query.
+		Scan(&rows).
 		Error
 	if err != nil {
 		return nil, err
 	}
-	dbReviews := make([]database.PullRequestReview, 0, len(reviews))
-	for _, vv := range reviews {
-		pr := PullRequest{}
-
-		// TEMP CHECK TO MAKE SURE THAT IT REPO NAME MATCHES
-		repo := vv.Repo
-		if !strings.Contains(repo, "decred") {
-			repo = "decred/" + repo
-		}
-		err := c.recordsdb.
-			Table(tableNamePullRequest).
-			Where("repo = ? AND number = ?",
-				repo,
-				vv.Number).
-			Find(&pr).
-			Error
-		if err != nil {
-			log.Errorf("pull request %v %v for review not found\n", repo, vv.Number)
-			continue
-		}
-		dbReview := DecodePullRequestReview(&vv)
-		dbReview.Additions = pr.Additions
-		dbReview.Deletions = pr.Deletions
+	dbReviews := make([]database.PullRequestReview, 0, len(rows))
+	for _, vv := range rows {
+		dbReview := DecodePullRequestReview(&vv.PullRequestReview)
+		dbReview.Repo = vv.PRRepo
+		dbReview.Additions = vv.PRAdditions
+		dbReview.Deletions = vv.PRDeletions
 		dbReviews = append(dbReviews, dbReview)
 	}
 	return dbReviews, nil
 }
 
-func (c *cockroachdb) AllUsersByDates(start, end int64) ([]string, error) {
-	log.Debugf("AllUsersByDates: %v %v", time.Unix(start, 0),
+// AllUsersByDates returns the authors of the pull requests of the passed
+// organization, or of all organizations when empty, that were merged or
+// reviewed from start up to but excluding end.
+//
+// AllUsersByDates satisfies the database interface.
+func (c *cockroachdb) AllUsersByDates(ctx context.Context, org string, start, end int64) ([]string, error) {
+	log.Debugf("AllUsersByDates: %v %v %v", org, time.Unix(start, 0),
 		time.Unix(end, 0))
 
-	type Users struct {
-		User string
+	tx := c.beginTx(ctx)
+	defer tx.Rollback()
+
+	// Get all distinct authors with merged PRs or submitted reviews
+	// between the given dates.
+	type author struct {
+		Author string
 	}
-	// Get all PRs from a user between the given dates.
-	usernames := make([]Users, 0, 1024) // PNOOMA
-	err := c.recordsdb.
-		Table(tableNamePullRequest).
-		Where("DISTINCT AND "+
-			"merged_at BETWEEN ? AND ?",
-			start,
-			end).
-		Find(&usernames).
+	authors := make([]author, 0, 1024) // PNOOMA
+	err := tx.
+		Raw("SELECT author FROM "+tableNamePullRequest+
+			" WHERE merged_at >= ? AND merged_at < ?"+
+			" AND (? = '' OR LOWER(organization) = LOWER(?))"+
+			" UNION SELECT "+tableNameReviews+".author FROM "+
+			tableNameReviews+" JOIN "+tableNamePullRequest+" ON "+
+			tableNamePullRequest+".url = "+
+			tableNameReviews+".pull_request_url"+
+			" WHERE submitted_at >= ? AND submitted_at < ?"+
+			" AND (? = '' OR LOWER("+tableNamePullRequest+
+			".organization) = LOWER(?))",
+			start, end, org, org, start, end, org, org).
+		Scan(&authors).
 		Error
 	if err != nil {
 		return nil, err
 	}
 
-	names := make([]string, 0, len(usernames))
-	for _, vv := range usernames {
-		names = append(names, vv.User)
+	names := make([]string, 0, len(authors))
+	for _, vv := range authors {
+		names = append(names, vv.Author)
 	}
 	return names, nil
 }
 
+// UserByLogin returns the user that currently uses the passed login along
+// with all of the logins the user has been seen with.  When no user currently
+// uses the login, the user that most recently used it is returned.
+//
+// UserByLogin satisfies the database interface.
+func (c *cockroachdb) UserByLogin(ctx context.Context, login string) (*database.User, error) {
+	log.Debugf("UserByLogin: %v", login)
+
+	tx := c.beginTx(ctx)
+	defer tx.Rollback()
+
+	var user User
+	err := tx.
+		Where("login = ?", login).
+		Order("last_seen DESC").
+		First(&user).
+		Error
+	if err == gorm.ErrRecordNotFound {
+		var userLogin UserLogin
+		err = tx.
+			Where("login = ?", login).
+			Order("last_seen DESC").
+			First(&userLogin).
+			Error
+		if err == gorm.ErrRecordNotFound {
+			return nil, database.ErrUserNotFound
+		} else if err != nil {
+			return nil, err
+		}
+		err = tx.
+			Where("id = ?", userLogin.UserID).
+			First(&user).
+			Error
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.
+		Where("user_id = ?", user.ID).
+		Order("last_seen DESC").
+		Find(&user.Logins).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeUser(&user), nil
+}
+
+// UpsertUser creates or updates the passed user and records all of its logins.
+//
+// UpsertUser satisfies the database interface.
+func (c *cockroachdb) UpsertUser(ctx context.Context, dbUser *database.User) error {
+	user := EncodeUser(dbUser)
+	seen := time.Now().Unix()
+
+	log.Debugf("UpsertUser: %v %v", user.ID, user.Login)
+
+	tx := c.beginTx(ctx)
+	err := upsertUsers(tx, []User{user}, seen)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, login := range dbUser.Logins {
+		err = tx.
+			Where(UserLogin{UserID: user.ID, Login: login}).
+			Attrs(UserLogin{FirstSeen: seen, LastSeen: seen}).
+			FirstOrCreate(&UserLogin{}).
+			Error
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+// restoreUserLoginQuery stores a user login, widening the time span of an
+// existing one.
+const restoreUserLoginQuery = "INSERT INTO " + tableNameUserLogins +
+	" (user_id, login, first_seen, last_seen) VALUES (?, ?, ?, ?)" +
+	" ON CONFLICT (user_id, login) DO UPDATE SET" +
+	" first_seen = LEAST(" + tableNameUserLogins + ".first_seen," +
+	" excluded.first_seen)," +
+	" last_seen = GREATEST(" + tableNameUserLogins + ".last_seen," +
+	" excluded.last_seen)"
+
+// RestoreUserLogin stores the passed user login along with the time span it
+// was seen.  The time span of an existing login is widened to include the
+// passed one.
+//
+// RestoreUserLogin satisfies the database interface.
+func (c *cockroachdb) RestoreUserLogin(ctx context.Context, dbLogin *database.UserLogin) error {
+	login := EncodeUserLogin(dbLogin)
+
+	log.Debugf("RestoreUserLogin: %v %v", login.UserID, login.Login)
+
+	tx := c.beginTx(ctx)
+	err := execStatement(tx, tableNameUserLogins, restoreUserLoginQuery,
+		login.UserID, login.Login, login.FirstSeen, login.LastSeen).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// UserLogins returns the login history of all users ordered by user ID and
+// the time the logins were last seen.
+//
+// UserLogins satisfies the database interface.
+func (c *cockroachdb) UserLogins(ctx context.Context) ([]database.UserLogin, error) {
+	log.Debugf("UserLogins")
+
+	tx := c.beginTx(ctx)
+	defer tx.Rollback()
+
+	var logins []UserLogin
+	err := tx.Order("user_id, last_seen DESC").Find(&logins).Error
+	if err != nil {
+		return nil, err
+	}
+
+	dbLogins := make([]database.UserLogin, 0, len(logins))
+	for _, login := range logins {
+		dbLogins = append(dbLogins, DecodeUserLogin(&login))
+	}
+	return dbLogins, nil
+}
+
+// Users returns all users along with their logins ordered by ID.
+//
+// Users satisfies the database interface.
+func (c *cockroachdb) Users(ctx context.Context) ([]database.User, error) {
+	log.Debugf("Users")
+
+	tx := c.beginTx(ctx)
+	defer tx.Rollback()
+
+	var users []User
+	err := tx.
+		Preload("Logins", func(db *gorm.DB) *gorm.DB {
+			return db.Order("last_seen DESC")
+		}).
+		Order("id").
+		Find(&users).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	dbUsers := make([]database.User, 0, len(users))
+	for _, user := range users {
+		dbUsers = append(dbUsers, *DecodeUser(&user))
+	}
+	return dbUsers, nil
+}
+
+// CommitsByUserDates returns the commits on pull requests of the passed
+// organization, or of all organizations when empty, authored by any of the
+// passed logins or author emails from start up to but excluding end.  The
+// repository of each commit is populated from the pull request it belongs to.
+//
+// CommitsByUserDates satisfies the database interface.
+func (c *cockroachdb) CommitsByUserDates(ctx context.Context, org string, logins, emails []string, start, end int64) ([]database.Commit, error) {
+	log.Debugf("CommitsByUserDates: %v %v %v", org, time.Unix(start, 0),
+		time.Unix(end, 0))
+
+	tx := c.beginTx(ctx)
+	defer tx.Rollback()
+
+	// An empty list would produce an invalid IN clause.
+	if len(logins) == 0 {
+		logins = []string{""}
+	}
+	if len(emails) == 0 {
+		emails = []string{""}
+	}
+
+	type commitRow struct {
+		Commit
+		PRRepo string
+	}
+	query := tx.
+		Table(tableNameCommits).
+		Select(tableNameCommits+".*, "+
+			tableNamePullRequest+".repo AS pr_repo").
+		Joins("JOIN "+tableNamePullRequest+" ON "+
+			tableNamePullRequest+".url = "+
+			tableNameCommits+".pull_request_url").
+		Where("("+tableNameCommits+".author IN (?) OR "+
+			tableNameCommits+".author_email IN (?)) AND "+
+			tableNameCommits+".authored_at >= ? AND "+
+			tableNameCommits+".authored_at < ?",
+			logins,
+			emails,
+			start,
+			end)
+	if org != "" {
+		query = query.Where("LOWER("+tableNamePullRequest+
+			".organization) = LOWER(?)", org)
+	}
+	rows := make([]commitRow, 0, 1024) // PNOOMA
+	err := query.
+		Scan(&rows).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	dbCommits := make([]database.Commit, 0, len(rows))
+	for _, vv := range rows {
+		dbCommit := DecodeCommit(&vv.Commit)
+		dbCommit.Repo = vv.PRRepo
+		dbCommits = append(dbCommits, dbCommit)
+	}
+	return dbCommits, nil
+}
+
 // Create new commit.
 //
 // NewCommit satisfies the database interface.
-func (c *cockroachdb) NewCommit(dbCommit *database.Commit) error {
+func (c *cockroachdb) NewCommit(ctx context.Context, dbCommit *database.Commit) error {
 	pr := EncodeCommit(dbCommit)
 
 	log.Debugf("NewCommit: %v", pr.URL)
-	return c.recordsdb.Create(&pr).Error
+
+	tx := c.beginTx(ctx)
+	err := tx.Create(&pr).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
 }
 
 // Update existing commit.
 //
 // UpdateCommit satisfies the database interface.
-func (c *cockroachdb) UpdateCommit(dbCommit *database.Commit) error {
+func (c *cockroachdb) UpdateCommit(ctx context.Context, dbCommit *database.Commit) error {
 	pr := EncodeCommit(dbCommit)
 
 	log.Debugf("UpdateCommit: %v", pr.URL)
-	return c.recordsdb.Save(&pr).Error
+
+	tx := c.beginTx(ctx)
+	err := tx.Save(&pr).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
 }
 
 // Create new review.
 //
 // NewPullRequestReview satisfies the database interface.
-func (c *cockroachdb) NewPullRequestReview(dbPullRequestReview *database.PullRequestReview) error {
+func (c *cockroachdb) NewPullRequestReview(ctx context.Context, dbPullRequestReview *database.PullRequestReview) error {
 	pr := EncodePullRequestReview(dbPullRequestReview)
 
 	log.Debugf("NewPullRequestReview: %v", pr.CommitID)
-	return c.recordsdb.Create(&pr).Error
+
+	tx := c.beginTx(ctx)
+	err := tx.Create(&pr).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
 }
 
 // Update existing review.
 //
 // UpdatePullRequestReview satisfies the database interface.
-func (c *cockroachdb) UpdatePullRequestReview(dbPullRequestReview *database.PullRequestReview) error {
+func (c *cockroachdb) UpdatePullRequestReview(ctx context.Context, dbPullRequestReview *database.PullRequestReview) error {
 	pr := EncodePullRequestReview(dbPullRequestReview)
 
 	log.Debugf("UpdatePullRequestReview: %v", pr.CommitID)
-	return c.recordsdb.Save(&pr).Error
+
+	tx := c.beginTx(ctx)
+	err := tx.Save(&pr).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
 }
 
 // This function must be called within a transaction.
@@ -220,32 +735,146 @@ func createGHTables(tx *gorm.DB) error {
 	log.Infof("createGHTables")
 
 	// Create cms tables
+	if !tx.HasTable(tableNameVersions) {
+		err := tx.CreateTable(&Version{}).Error
+		if err != nil {
+			return err
+		}
+	}
+	if !tx.HasTable(tableNameOrganization) {
+		err := tx.CreateTable(&Organization{}).Error
+		if err != nil {
+			return err
+		}
+	}
+	if !tx.HasTable(tableNameRepositories) {
+		err := tx.CreateTable(&Repository{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&Repository{}).
+			AddForeignKey("organization_id",
+				tableNameOrganization+"(id)", "CASCADE", "CASCADE").
+			Error
+		if err != nil {
+			return err
+		}
+	}
+	if !tx.HasTable(tableNameRepositoryNames) {
+		// The name history does not reference the repositories table
+		// so that it outlives the repositories dropped by Build.
+		err := tx.CreateTable(&RepositoryName{}).Error
+		if err != nil {
+			return err
+		}
+	}
 	if !tx.HasTable(tableNamePullRequest) {
 		err := tx.CreateTable(&PullRequest{}).Error
 		if err != nil {
 			return err
 		}
+		err = tx.Model(&PullRequest{}).
+			AddForeignKey("repo_id", tableNameRepositories+"(id)",
+				"CASCADE", "CASCADE").
+			Error
+		if err != nil {
+			return err
+		}
+	}
+	if !tx.HasTable(tableNameUsers) {
+		err := tx.CreateTable(&User{}).Error
+		if err != nil {
+			return err
+		}
+	}
+	if !tx.HasTable(tableNameUserLogins) {
+		err := tx.CreateTable(&UserLogin{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&UserLogin{}).
+			AddForeignKey("user_id", tableNameUsers+"(id)",
+				"CASCADE", "CASCADE").
+			Error
+		if err != nil {
+			return err
+		}
+	}
+	if !tx.HasTable(tableNameAliases) {
+		err := tx.CreateTable(&Alias{}).Error
+		if err != nil {
+			return err
+		}
+	}
+	if !tx.HasTable(tableNameChanges) {
+		err := tx.CreateTable(&Change{}).Error
+		if err != nil {
+			return err
+		}
+	}
+	if !tx.HasTable(tableNameSnapshots) {
+		err := tx.CreateTable(&Snapshot{}).Error
+		if err != nil {
+			return err
+		}
+	}
+	if !tx.HasTable(tableNameRollups) {
+		err := tx.CreateTable(&Rollup{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&Rollup{}).
+			AddForeignKey("repo_id", tableNameRepositories+"(id)",
+				"CASCADE", "CASCADE").
+			Error
+		if err != nil {
+			return err
+		}
+	}
+	if !tx.HasTable(tableNameAPIKeys) {
+		err := tx.CreateTable(&APIKey{}).Error
+		if err != nil {
+			return err
+		}
 	}
-	if !tx.HasTable(tableNameCommits) {
-		err := tx.CreateTable(&Commit{}).Error
+	if !tx.HasTable(tableNameErasedIdentities) {
+		err := tx.CreateTable(&ErasedIdentity{}).Error
 		if err != nil {
 			return err
 		}
 	}
-	if !tx.HasTable(tableNameReviews) {
-		err := tx.CreateTable(&PullRequestReview{}).Error
+	childTables := []struct {
+		name  string
+		model interface{}
+	}{
+		{tableNameCommits, &Commit{}},
+		{tableNameReviews, &PullRequestReview{}},
+		{tableNameLabels, &Label{}},
+		{tableNameRequestedReviewers, &RequestedReviewer{}},
+	}
+	for _, t := range childTables {
+		if tx.HasTable(t.name) {
+			continue
+		}
+		err := tx.CreateTable(t.model).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(t.model).
+			AddForeignKey("pull_request_url",
+				tableNamePullRequest+"(url)", "CASCADE", "CASCADE").
+			Error
 		if err != nil {
 			return err
 		}
 	}
 
 	return nil
-
 }
 
 // Setup calls the tables creation function to ensure the database is prepared for use.
-func (c *cockroachdb) Setup() error {
-	tx := c.recordsdb.Begin()
+func (c *cockroachdb) Setup(ctx context.Context) error {
+	tx := c.beginTx(ctx)
 	err := createGHTables(tx)
 	if err != nil {
 		tx.Rollback()
@@ -255,34 +884,164 @@ func (c *cockroachdb) Setup() error {
 	return tx.Commit().Error
 }
 
-func buildQueryString(user, rootCert, cert, key string) string {
+// preservedModels are the models of the tables that Build keeps.  They hold
+// data that is managed by operators, such as aliases, API keys and erased
+// identities, or data that can not be refetched from GitHub, such as the login
+// history of users, the name history of repositories, the change history and
+// monthly snapshots.
+var preservedModels = []interface{}{
+	&User{},
+	&UserLogin{},
+	&RepositoryName{},
+	&Alias{},
+	&Change{},
+	&Snapshot{},
+	&APIKey{},
+	&ErasedIdentity{},
+}
+
+// Build drops all tables that hold data fetched from GitHub, recreates them and
+// sets the version record.  The dropped data is refetched from GitHub during
+// the next update.  The tables of preservedModels are kept and only gain the
+// columns and indexes they are missing.
+//
+// Build satisfies the database interface.
+func (c *cockroachdb) Build(ctx context.Context) error {
+	log.Infof("Building database")
+
+	tx := c.beginTx(ctx)
+
+	// Databases created by older versions have the name history reference
+	// the repositories, which would prevent them from being dropped.
+	err := tx.Model(&RepositoryName{}).
+		RemoveForeignKey("repository_id", tableNameRepositories+"(id)").
+		Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = tx.DropTableIfExists(&Rollup{}, &RequestedReviewer{}, &Label{},
+		&PullRequestReview{}, &Commit{}, &PullRequest{}, &Repository{},
+		&Organization{}, &Version{}).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = createGHTables(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = tx.AutoMigrate(preservedModels...).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = tx.Create(&Version{
+		ID:        cacheID,
+		Version:   ghVersion,
+		Timestamp: time.Now().Unix(),
+	}).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// Options contains the settings used to connect to the database.  Either a
+// full DSN may be provided, in which case all other settings are ignored, or
+// the connection string is built from the discrete settings.  Client
+// certificates are only required for CockroachDB certificate authentication;
+// plain PostgreSQL deployments will typically use a password instead.
+type Options struct {
+	DSN      string // Full connection string
+	Host     string // Database ip:port
+	User     string // Database user, defaults to githubtracker
+	Password string // Database password for password authentication
+	DBName   string // Database name, defaults to ght
+	SSLMode  string // SSL mode, defaults to require
+	RootCert string // CA certificate
+	Cert     string // Client certificate
+	Key      string // Client certificate key
+}
+
+func buildQueryString(opts Options) string {
+	sslMode := opts.SSLMode
+	if sslMode == "" {
+		sslMode = "require"
+	}
 	v := url.Values{}
-	v.Set("sslmode", "require")
-	v.Set("sslrootcert", filepath.Clean(rootCert))
-	v.Set("sslcert", filepath.Join(cert))
-	v.Set("sslkey", filepath.Join(key))
+	v.Set("sslmode", sslMode)
+	if opts.RootCert != "" {
+		v.Set("sslrootcert", filepath.Clean(opts.RootCert))
+	}
+	if opts.Cert != "" {
+		v.Set("sslcert", filepath.Clean(opts.Cert))
+	}
+	if opts.Key != "" {
+		v.Set("sslkey", filepath.Clean(opts.Key))
+	}
 	return v.Encode()
 }
 
-// New returns a new cockroachdb context that contains a connection to the
-// specified database that was made using the politeiawww user and the passed
-// in certificates.
-func New(host, rootCert, cert, key string) (*cockroachdb, error) {
-	log.Tracef("New: %v %v %v %v %v", host, rootCert, cert, key)
+// buildDSN returns the connection string described by the passed options.
+func buildDSN(opts Options) string {
+	if opts.DSN != "" {
+		return opts.DSN
+	}
 
-	// Connect to database
-	dbName := cacheID
-	h := "postgresql://" + userGithubTracker + "@" + host + "/" + dbName
-	u, err := url.Parse(h)
-	if err != nil {
-		return nil, fmt.Errorf("parse url '%v': %v", h, err)
+	user := opts.User
+	if user == "" {
+		user = userGithubTracker
+	}
+	dbName := opts.DBName
+	if dbName == "" {
+		dbName = cacheID
+	}
+
+	u := url.URL{
+		Scheme:   "postgresql",
+		Host:     opts.Host,
+		Path:     "/" + dbName,
+		RawQuery: buildQueryString(opts),
+	}
+	if opts.Password != "" {
+		u.User = url.UserPassword(user, opts.Password)
+	} else {
+		u.User = url.User(user)
 	}
+	return u.String()
+}
 
-	qs := buildQueryString(u.User.String(), rootCert, cert, key)
-	addr := u.String() + "?" + qs
+// redactDSN returns the passed connection string with any password removed
+// so that it may be safely logged.
+func redactDSN(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil || u.User == nil {
+		// Key/value style connection strings are not parsed and are
+		// never logged.
+		return "<dsn>"
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	}
+	return u.String()
+}
+
+// New returns a new cockroachdb context that contains a connection to the
+// database described by the passed options.  The connection uses the postgres
+// wire protocol, so both CockroachDB and plain PostgreSQL are supported.
+func New(opts Options) (*cockroachdb, error) {
+	addr := buildDSN(opts)
+	log.Tracef("New: %v", redactDSN(addr))
+
+	// Connect to database
 	db, err := gorm.Open("postgres", addr)
 	if err != nil {
-		return nil, fmt.Errorf("connect to database '%v': %v", addr, err)
+		return nil, fmt.Errorf("connect to database '%v': %v",
+			redactDSN(addr), err)
 	}
 
 	// Create context
@@ -298,31 +1057,38 @@ func New(host, rootCert, cert, key string) (*cockroachdb, error) {
 	// names manually.
 	c.recordsdb.SingularTable(true)
 
-	/*
-		// Return an error if the version record is not found or
-		// if there is a version mismatch, but also return the
-		// cache context so that the cache can be built/rebuilt.
-		if !c.recordsdb.HasTable(tableNameVersions) {
-			log.Debugf("table '%v' does not exist", tableNameVersions)
-			return c, database.ErrNoVersionRecord
-		}
-			var v Version
-			err = c.recordsdb.
-				Where("id = ?", cacheID).
-				Find(&v).
-				Error
-			if err == gorm.ErrRecordNotFound {
-				log.Debugf("version record not found for ID '%v'", cacheID)
-				err = database.ErrNoVersionRecord
-			} else if v.Version != ghVersion {
-				log.Debugf("version mismatch for ID '%v': got %v, want %v",
-					cacheID, v.Version, ghVersion)
-				err = database.ErrWrongVersion
-			}
-	*/
+	// Observe the latency of queries.
+	instrumentQueries(c.recordsdb)
+
+	// Return an error if the version record is not found or
+	// if there is a version mismatch, but also return the
+	// database context so that the database can be built/rebuilt.
+	if !c.recordsdb.HasTable(tableNameVersions) {
+		log.Debugf("table '%v' does not exist", tableNameVersions)
+		return c, database.ErrNoVersionRecord
+	}
+	var v Version
+	err = c.recordsdb.
+		Where("id = ?", cacheID).
+		Find(&v).
+		Error
+	if err == gorm.ErrRecordNotFound {
+		log.Debugf("version record not found for ID '%v'", cacheID)
+		err = database.ErrNoVersionRecord
+	} else if err == nil && v.Version != ghVersion {
+		log.Debugf("version mismatch for ID '%v': got %v, want %v",
+			cacheID, v.Version, ghVersion)
+		err = database.ErrWrongVersion
+	}
+
 	return c, err
 }
 
+// Ping satisfies the database interface.
+func (c *cockroachdb) Ping(ctx context.Context) error {
+	return c.recordsdb.DB().PingContext(ctx)
+}
+
 // Close satisfies the database interface.
 func (c *cockroachdb) Close() error {
 	return c.recordsdb.Close()
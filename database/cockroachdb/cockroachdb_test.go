@@ -0,0 +1,99 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package cockroachdb
+
+import "testing"
+
+func TestBuildDSN(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{
+			name: "dsn",
+			opts: Options{
+				DSN:  "postgresql://u:p@db:26257/x?sslmode=disable",
+				Host: "ignored:26257",
+				User: "ignored",
+			},
+			want: "postgresql://u:p@db:26257/x?sslmode=disable",
+		},
+		{
+			name: "defaults",
+			opts: Options{Host: "localhost:26257"},
+			want: "postgresql://githubtracker@localhost:26257/ght?sslmode=require",
+		},
+		{
+			name: "password",
+			opts: Options{
+				Host:     "db:5432",
+				User:     "tracker",
+				Password: "s3cret",
+				DBName:   "tracker",
+				SSLMode:  "verify-full",
+			},
+			want: "postgresql://tracker:s3cret@db:5432/tracker?sslmode=verify-full",
+		},
+		{
+			name: "certificates",
+			opts: Options{
+				Host:     "localhost:26257",
+				RootCert: "/certs/ca.crt",
+				Cert:     "/certs/client.githubtracker.crt",
+				Key:      "/certs/../certs/client.githubtracker.key",
+			},
+			want: "postgresql://githubtracker@localhost:26257/ght?" +
+				"sslcert=%2Fcerts%2Fclient.githubtracker.crt&" +
+				"sslkey=%2Fcerts%2Fclient.githubtracker.key&" +
+				"sslmode=require&sslrootcert=%2Fcerts%2Fca.crt",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := buildDSN(test.opts)
+			if got != test.want {
+				t.Fatalf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRedactDSN(t *testing.T) {
+	tests := []struct {
+		name string
+		dsn  string
+		want string
+	}{
+		{
+			name: "password",
+			dsn:  "postgresql://tracker:s3cret@db:5432/ght?sslmode=require",
+			want: "postgresql://tracker:xxxxx@db:5432/ght?sslmode=require",
+		},
+		{
+			name: "no password",
+			dsn:  "postgresql://githubtracker@localhost:26257/ght?sslmode=require",
+			want: "postgresql://githubtracker@localhost:26257/ght?sslmode=require",
+		},
+		{
+			name: "no user",
+			dsn:  "postgresql://localhost:26257/ght",
+			want: "<dsn>",
+		},
+		{
+			name: "key value",
+			dsn:  "host=db user=tracker password=s3cret",
+			want: "<dsn>",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := redactDSN(test.dsn)
+			if got != test.want {
+				t.Fatalf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -11,11 +11,18 @@ import (
 // EncodeCommit encodes a database.Commit into a cockroachdb Commit.
 func EncodeCommit(dbCommit *database.Commit) Commit {
 	commit := Commit{}
+	commit.PullRequestURL = dbCommit.PullRequestURL
 	commit.URL = dbCommit.URL
 	commit.SHA = dbCommit.SHA
 	commit.Message = dbCommit.Message
 	commit.Author = dbCommit.Author
+	commit.AuthorID = dbCommit.AuthorID
+	commit.AuthorEmail = dbCommit.AuthorEmail
+	commit.AuthoredAt = dbCommit.AuthoredAt
 	commit.Committer = dbCommit.Committer
+	commit.CommitterID = dbCommit.CommitterID
+	commit.Additions = dbCommit.Additions
+	commit.Deletions = dbCommit.Deletions
 
 	return commit
 }
@@ -23,11 +30,18 @@ func EncodeCommit(dbCommit *database.Commit) Commit {
 // DecodeCommit decodes a cockroachdb Commit into a generic database.Commit
 func DecodeCommit(commit *Commit) database.Commit {
 	dbCommit := database.Commit{}
+	dbCommit.PullRequestURL = commit.PullRequestURL
 	dbCommit.URL = commit.URL
 	dbCommit.SHA = commit.SHA
 	dbCommit.Message = commit.Message
 	dbCommit.Author = commit.Author
+	dbCommit.AuthorID = commit.AuthorID
+	dbCommit.AuthorEmail = commit.AuthorEmail
+	dbCommit.AuthoredAt = commit.AuthoredAt
 	dbCommit.Committer = commit.Committer
+	dbCommit.CommitterID = commit.CommitterID
+	dbCommit.Additions = commit.Additions
+	dbCommit.Deletions = commit.Deletions
 
 	return dbCommit
 }
@@ -36,10 +50,12 @@ func DecodeCommit(commit *Commit) database.Commit {
 func EncodePullRequestReview(dbPullRequestReview *database.PullRequestReview) PullRequestReview {
 	prReview := PullRequestReview{}
 	prReview.Author = dbPullRequestReview.Author
+	prReview.AuthorID = dbPullRequestReview.AuthorID
 	prReview.State = dbPullRequestReview.State
 	prReview.SubmittedAt = dbPullRequestReview.SubmittedAt
 	prReview.CommitID = dbPullRequestReview.CommitID
 	prReview.ID = dbPullRequestReview.ID
+	prReview.PullRequestURL = dbPullRequestReview.PullRequestURL
 	prReview.Number = dbPullRequestReview.Number
 	prReview.Repo = dbPullRequestReview.Repo
 
@@ -50,10 +66,12 @@ func EncodePullRequestReview(dbPullRequestReview *database.PullRequestReview) Pu
 func DecodePullRequestReview(prReview *PullRequestReview) database.PullRequestReview {
 	dbPullRequestReview := database.PullRequestReview{}
 	dbPullRequestReview.Author = prReview.Author
+	dbPullRequestReview.AuthorID = prReview.AuthorID
 	dbPullRequestReview.State = prReview.State
 	dbPullRequestReview.SubmittedAt = prReview.SubmittedAt
 	dbPullRequestReview.CommitID = prReview.CommitID
 	dbPullRequestReview.ID = prReview.ID
+	dbPullRequestReview.PullRequestURL = prReview.PullRequestURL
 	dbPullRequestReview.Repo = prReview.Repo
 	dbPullRequestReview.Number = prReview.Number
 
@@ -63,11 +81,13 @@ func DecodePullRequestReview(prReview *PullRequestReview) database.PullRequestRe
 // EncodePullRequest encodes a database.PullRequest into a cockroachdb PullRequest.
 func EncodePullRequest(dbPullRequest *database.PullRequest) PullRequest {
 	pr := PullRequest{}
+	pr.RepoID = dbPullRequest.RepoID
 	pr.URL = dbPullRequest.URL
 	pr.Repo = dbPullRequest.Repo
 	pr.Organization = dbPullRequest.Organization
 	pr.Number = dbPullRequest.Number
 	pr.Author = dbPullRequest.User
+	pr.AuthorID = dbPullRequest.UserID
 	pr.State = dbPullRequest.State
 	pr.UpdatedAt = dbPullRequest.UpdatedAt
 	pr.ClosedAt = dbPullRequest.ClosedAt
@@ -76,29 +96,63 @@ func EncodePullRequest(dbPullRequest *database.PullRequest) PullRequest {
 	pr.Additions = dbPullRequest.Additions
 	pr.Deletions = dbPullRequest.Deletions
 	pr.MergedBy = dbPullRequest.MergedBy
+	pr.Title = dbPullRequest.Title
+	pr.Body = dbPullRequest.Body
+	pr.BaseRef = dbPullRequest.BaseRef
+	pr.HeadRef = dbPullRequest.HeadRef
+	pr.HeadRepo = dbPullRequest.HeadRepo
+	pr.Fork = dbPullRequest.Fork
+	pr.Draft = dbPullRequest.Draft
+	pr.CreatedAt = dbPullRequest.CreatedAt
+	pr.Milestone = dbPullRequest.Milestone
 
 	commits := make([]Commit, 0, len(dbPullRequest.Commits))
 	for _, dbCommit := range dbPullRequest.Commits {
-		commits = append(commits, EncodeCommit(&dbCommit))
+		commit := EncodeCommit(&dbCommit)
+		commit.PullRequestURL = pr.URL
+		commits = append(commits, commit)
 	}
 
 	reviews := make([]PullRequestReview, 0, len(dbPullRequest.Reviews))
 	for _, dbReview := range dbPullRequest.Reviews {
-		reviews = append(reviews, EncodePullRequestReview(&dbReview))
+		review := EncodePullRequestReview(&dbReview)
+		review.PullRequestURL = pr.URL
+		reviews = append(reviews, review)
 	}
+	labels := make([]Label, 0, len(dbPullRequest.Labels))
+	for _, name := range dbPullRequest.Labels {
+		labels = append(labels, Label{
+			PullRequestURL: pr.URL,
+			Name:           name,
+		})
+	}
+
+	requestedReviewers := make([]RequestedReviewer, 0,
+		len(dbPullRequest.RequestedReviewers))
+	for _, login := range dbPullRequest.RequestedReviewers {
+		requestedReviewers = append(requestedReviewers, RequestedReviewer{
+			PullRequestURL: pr.URL,
+			Login:          login,
+		})
+	}
+
 	pr.Reviews = reviews
 	pr.Commits = commits
+	pr.Labels = labels
+	pr.RequestedReviewers = requestedReviewers
 	return pr
 }
 
 // DecodePullRequest decodes a cockroachdb PullRequest into a generic database.PullRequest
 func DecodePullRequest(pr *PullRequest) *database.PullRequest {
 	dbPullRequest := &database.PullRequest{}
+	dbPullRequest.RepoID = pr.RepoID
 	dbPullRequest.URL = pr.URL
 	dbPullRequest.Repo = pr.Repo
 	dbPullRequest.Organization = pr.Organization
 	dbPullRequest.Number = pr.Number
 	dbPullRequest.User = pr.Author
+	dbPullRequest.UserID = pr.AuthorID
 	dbPullRequest.State = pr.State
 	dbPullRequest.UpdatedAt = pr.UpdatedAt
 	dbPullRequest.ClosedAt = pr.ClosedAt
@@ -107,6 +161,15 @@ func DecodePullRequest(pr *PullRequest) *database.PullRequest {
 	dbPullRequest.Additions = pr.Additions
 	dbPullRequest.Deletions = pr.Deletions
 	dbPullRequest.MergedBy = pr.MergedBy
+	dbPullRequest.Title = pr.Title
+	dbPullRequest.Body = pr.Body
+	dbPullRequest.BaseRef = pr.BaseRef
+	dbPullRequest.HeadRef = pr.HeadRef
+	dbPullRequest.HeadRepo = pr.HeadRepo
+	dbPullRequest.Fork = pr.Fork
+	dbPullRequest.Draft = pr.Draft
+	dbPullRequest.CreatedAt = pr.CreatedAt
+	dbPullRequest.Milestone = pr.Milestone
 
 	dbCommits := make([]database.Commit, 0, len(pr.Commits))
 	for _, commit := range pr.Commits {
@@ -117,8 +180,288 @@ func DecodePullRequest(pr *PullRequest) *database.PullRequest {
 	for _, review := range pr.Reviews {
 		dbReviews = append(dbReviews, DecodePullRequestReview(&review))
 	}
+	dbLabels := make([]string, 0, len(pr.Labels))
+	for _, label := range pr.Labels {
+		dbLabels = append(dbLabels, label.Name)
+	}
+
+	dbRequestedReviewers := make([]string, 0, len(pr.RequestedReviewers))
+	for _, reviewer := range pr.RequestedReviewers {
+		dbRequestedReviewers = append(dbRequestedReviewers, reviewer.Login)
+	}
+
 	dbPullRequest.Reviews = dbReviews
 	dbPullRequest.Commits = dbCommits
+	dbPullRequest.Labels = dbLabels
+	dbPullRequest.RequestedReviewers = dbRequestedReviewers
 
 	return dbPullRequest
 }
+
+// DecodeOrganization decodes a cockroachdb Organization into a generic
+// database.Organization
+func DecodeOrganization(org *Organization) database.Organization {
+	dbOrg := database.Organization{}
+	dbOrg.ID = org.ID
+	dbOrg.Login = org.Login
+	dbOrg.LastSync = org.LastSync
+
+	return dbOrg
+}
+
+// EncodeRepository encodes a database.Repository into a cockroachdb
+// Repository.  The last sync time and name history are maintained by the
+// database and are not encoded.
+func EncodeRepository(dbRepo *database.Repository) Repository {
+	repo := Repository{}
+	repo.ID = dbRepo.ID
+	repo.OrganizationID = dbRepo.OrganizationID
+	repo.Name = dbRepo.Name
+	repo.FullName = dbRepo.FullName
+	repo.DefaultBranch = dbRepo.DefaultBranch
+	repo.Fork = dbRepo.Fork
+	repo.Archived = dbRepo.Archived
+	repo.Private = dbRepo.Private
+
+	return repo
+}
+
+// DecodeRepository decodes a cockroachdb Repository into a generic
+// database.Repository.  The organization login is not part of the model and
+// must be set by the caller.
+func DecodeRepository(repo *Repository) *database.Repository {
+	dbRepo := &database.Repository{}
+	dbRepo.ID = repo.ID
+	dbRepo.OrganizationID = repo.OrganizationID
+	dbRepo.Name = repo.Name
+	dbRepo.FullName = repo.FullName
+	dbRepo.DefaultBranch = repo.DefaultBranch
+	dbRepo.Fork = repo.Fork
+	dbRepo.Archived = repo.Archived
+	dbRepo.Private = repo.Private
+	dbRepo.LastSync = repo.LastSync
+
+	dbRepo.Names = make([]string, 0, len(repo.Names))
+	for _, name := range repo.Names {
+		dbRepo.Names = append(dbRepo.Names, name.FullName)
+	}
+
+	return dbRepo
+}
+
+// EncodeRepositoryName encodes a database.RepositoryName into a cockroachdb
+// RepositoryName.
+func EncodeRepositoryName(dbName *database.RepositoryName) RepositoryName {
+	name := RepositoryName{}
+	name.RepositoryID = dbName.RepositoryID
+	name.FullName = dbName.FullName
+	name.FirstSeen = dbName.FirstSeen
+	name.LastSeen = dbName.LastSeen
+
+	return name
+}
+
+// DecodeRepositoryName decodes a cockroachdb RepositoryName into a generic
+// database.RepositoryName
+func DecodeRepositoryName(name *RepositoryName) database.RepositoryName {
+	dbName := database.RepositoryName{}
+	dbName.RepositoryID = name.RepositoryID
+	dbName.FullName = name.FullName
+	dbName.FirstSeen = name.FirstSeen
+	dbName.LastSeen = name.LastSeen
+
+	return dbName
+}
+
+// EncodeUser encodes a database.User into a cockroachdb User.  The login
+// history is maintained by the database and is not encoded.
+func EncodeUser(dbUser *database.User) User {
+	user := User{}
+	user.ID = dbUser.ID
+	user.NodeID = dbUser.NodeID
+	user.Login = dbUser.Login
+	user.Type = dbUser.Type
+
+	return user
+}
+
+// DecodeUser decodes a cockroachdb User into a generic database.User
+func DecodeUser(user *User) *database.User {
+	dbUser := &database.User{}
+	dbUser.ID = user.ID
+	dbUser.NodeID = user.NodeID
+	dbUser.Login = user.Login
+	dbUser.Type = user.Type
+
+	dbUser.Logins = make([]string, 0, len(user.Logins))
+	for _, login := range user.Logins {
+		dbUser.Logins = append(dbUser.Logins, login.Login)
+	}
+
+	return dbUser
+}
+
+// EncodeUserLogin encodes a database.UserLogin into a cockroachdb UserLogin.
+func EncodeUserLogin(dbLogin *database.UserLogin) UserLogin {
+	login := UserLogin{}
+	login.UserID = dbLogin.UserID
+	login.Login = dbLogin.Login
+	login.FirstSeen = dbLogin.FirstSeen
+	login.LastSeen = dbLogin.LastSeen
+
+	return login
+}
+
+// DecodeUserLogin decodes a cockroachdb UserLogin into a generic
+// database.UserLogin
+func DecodeUserLogin(login *UserLogin) database.UserLogin {
+	dbLogin := database.UserLogin{}
+	dbLogin.UserID = login.UserID
+	dbLogin.Login = login.Login
+	dbLogin.FirstSeen = login.FirstSeen
+	dbLogin.LastSeen = login.LastSeen
+
+	return dbLogin
+}
+
+// EncodeAlias encodes a database.Alias into a cockroachdb Alias.
+func EncodeAlias(dbAlias *database.Alias) Alias {
+	alias := Alias{}
+	alias.Contributor = dbAlias.Contributor
+	alias.Type = dbAlias.Type
+	alias.Value = dbAlias.Value
+
+	return alias
+}
+
+// DecodeAlias decodes a cockroachdb Alias into a generic database.Alias
+func DecodeAlias(alias *Alias) database.Alias {
+	dbAlias := database.Alias{}
+	dbAlias.Contributor = alias.Contributor
+	dbAlias.Type = alias.Type
+	dbAlias.Value = alias.Value
+
+	return dbAlias
+}
+
+// DecodeChange decodes a cockroachdb Change into a generic database.Change
+func DecodeChange(change *Change) database.Change {
+	dbChange := database.Change{}
+	dbChange.ID = change.ID
+	dbChange.RepoID = change.RepoID
+	dbChange.Number = change.Number
+	dbChange.PullRequestURL = change.PullRequestURL
+	dbChange.Record = change.Record
+	dbChange.RecordID = change.RecordID
+	dbChange.Action = change.Action
+	dbChange.Field = change.Field
+	dbChange.Old = change.Old
+	dbChange.New = change.New
+	dbChange.ChangedAt = change.ChangedAt
+
+	return dbChange
+}
+
+// EncodeSnapshot encodes a database.Snapshot into a cockroachdb Snapshot.
+func EncodeSnapshot(dbSnapshot *database.Snapshot) Snapshot {
+	snapshot := Snapshot{}
+	snapshot.Organization = dbSnapshot.Organization
+	snapshot.Login = dbSnapshot.User
+	snapshot.Year = dbSnapshot.Year
+	snapshot.Month = dbSnapshot.Month
+	snapshot.Payload = dbSnapshot.Payload
+	snapshot.Hash = dbSnapshot.Hash
+	snapshot.CreatedAt = dbSnapshot.CreatedAt
+
+	return snapshot
+}
+
+// DecodeSnapshot decodes a cockroachdb Snapshot into a generic
+// database.Snapshot
+func DecodeSnapshot(snapshot *Snapshot) *database.Snapshot {
+	dbSnapshot := &database.Snapshot{}
+	dbSnapshot.Organization = snapshot.Organization
+	dbSnapshot.User = snapshot.Login
+	dbSnapshot.Year = snapshot.Year
+	dbSnapshot.Month = snapshot.Month
+	dbSnapshot.Payload = snapshot.Payload
+	dbSnapshot.Hash = snapshot.Hash
+	dbSnapshot.CreatedAt = snapshot.CreatedAt
+
+	return dbSnapshot
+}
+
+// DecodeRollup decodes a cockroachdb Rollup into a generic database.Rollup.
+// The repository and organization names are not part of the model and must be
+// set by the caller.
+func DecodeRollup(rollup *Rollup) database.Rollup {
+	dbRollup := database.Rollup{}
+	dbRollup.Login = rollup.Author
+	dbRollup.UserID = rollup.UserID
+	dbRollup.Author = rollup.Author
+	dbRollup.RepoID = rollup.RepoID
+	dbRollup.Month = rollup.Month
+	dbRollup.PullRequests = rollup.PullRequests
+	dbRollup.MergedAdditions = rollup.MergedAdditions
+	dbRollup.MergedDeletions = rollup.MergedDeletions
+	dbRollup.Reviews = rollup.Reviews
+	dbRollup.ReviewedAdditions = rollup.ReviewedAdditions
+	dbRollup.ReviewedDeletions = rollup.ReviewedDeletions
+	dbRollup.Commits = rollup.Commits
+	dbRollup.CommitAdditions = rollup.CommitAdditions
+	dbRollup.CommitDeletions = rollup.CommitDeletions
+
+	return dbRollup
+}
+
+// EncodeAPIKey encodes a database.APIKey into a cockroachdb APIKey.
+func EncodeAPIKey(dbKey *database.APIKey) APIKey {
+	key := APIKey{}
+	key.ID = dbKey.ID
+	key.Name = dbKey.Name
+	key.Hash = dbKey.Hash
+	key.Scope = dbKey.Scope
+	key.CreatedAt = dbKey.CreatedAt
+	key.ExpiresAt = dbKey.ExpiresAt
+	key.LastUsedAt = dbKey.LastUsedAt
+
+	return key
+}
+
+// DecodeAPIKey decodes a cockroachdb APIKey into a generic database.APIKey
+func DecodeAPIKey(key *APIKey) *database.APIKey {
+	dbKey := &database.APIKey{}
+	dbKey.ID = key.ID
+	dbKey.Name = key.Name
+	dbKey.Hash = key.Hash
+	dbKey.Scope = key.Scope
+	dbKey.CreatedAt = key.CreatedAt
+	dbKey.ExpiresAt = key.ExpiresAt
+	dbKey.LastUsedAt = key.LastUsedAt
+
+	return dbKey
+}
+
+// EncodeErasedIdentity encodes a database.ErasedIdentity into a cockroachdb
+// ErasedIdentity.
+func EncodeErasedIdentity(dbErased *database.ErasedIdentity) ErasedIdentity {
+	erased := ErasedIdentity{}
+	erased.Type = dbErased.Type
+	erased.Value = dbErased.Value
+	erased.Pseudonym = dbErased.Pseudonym
+	erased.ErasedAt = dbErased.ErasedAt
+
+	return erased
+}
+
+// DecodeErasedIdentity decodes a cockroachdb ErasedIdentity into a generic
+// database.ErasedIdentity
+func DecodeErasedIdentity(erased *ErasedIdentity) database.ErasedIdentity {
+	dbErased := database.ErasedIdentity{}
+	dbErased.Type = erased.Type
+	dbErased.Value = erased.Value
+	dbErased.Pseudonym = erased.Pseudonym
+	dbErased.ErasedAt = erased.ErasedAt
+
+	return dbErased
+}
@@ -0,0 +1,524 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package cockroachdb
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/decred/github-tracker/database"
+	"github.com/jinzhu/gorm"
+)
+
+// validateIdentity returns ErrInvalidIdentity when the passed identity has no
+// logins or an empty login or email.
+func validateIdentity(identity *database.UserIdentity) error {
+	if len(identity.Logins) == 0 {
+		return database.ErrInvalidIdentity
+	}
+	for _, values := range [][]string{identity.Logins, identity.Emails} {
+		for _, value := range values {
+			if strings.TrimSpace(value) == "" {
+				return database.ErrInvalidIdentity
+			}
+		}
+	}
+	return nil
+}
+
+// identityUserIDs returns the IDs of the GitHub accounts of the identity along
+// with those that use or used any of the passed logins.
+//
+// This function must be called within a transaction.
+func identityUserIDs(tx *gorm.DB, identity *database.UserIdentity) ([]int64, error) {
+	logins := identity.Logins
+
+	var ids []int64
+	err := tx.
+		Model(&UserLogin{}).
+		Where("login IN (?)", logins).
+		Pluck("DISTINCT user_id", &ids).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	var userIDs []int64
+	err = tx.
+		Model(&User{}).
+		Where("login IN (?)", logins).
+		Pluck("id", &userIDs).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	ids = append(ids, userIDs...)
+	return append(ids, identity.UserIDs...), nil
+}
+
+// recordErasure records the account IDs, logins and emails of the erased
+// identity.  An existing record of an identity is replaced.
+//
+// This function must be called within a transaction.
+func recordErasure(tx *gorm.DB, identity *database.UserIdentity, ids []int64, pseudonym string) error {
+	erased := database.NewErasedIdentities(identity, ids, pseudonym,
+		time.Now().Unix())
+	for i := range erased {
+		e := EncodeErasedIdentity(&erased[i])
+		err := tx.Save(&e).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pullRequestErasures returns the erasures that match the author, reviewers,
+// commit authors and committers and accounts of the passed pull request.
+//
+// This function must be called within a transaction.
+func pullRequestErasures(tx *gorm.DB, pr *database.PullRequest) (*database.Erasures, error) {
+	values := make([]string, 0, 16)
+	add := func(userID int64, login string) {
+		if userID != 0 {
+			values = append(values, strconv.FormatInt(userID, 10))
+		}
+		if login != "" {
+			values = append(values, strings.ToLower(login))
+		}
+	}
+	add(pr.UserID, pr.User)
+	add(0, pr.MergedBy)
+	for _, review := range pr.Reviews {
+		add(review.AuthorID, review.Author)
+	}
+	for _, commit := range pr.Commits {
+		add(commit.AuthorID, commit.Author)
+		add(commit.CommitterID, commit.Committer)
+		add(0, commit.AuthorEmail)
+	}
+	for _, login := range pr.RequestedReviewers {
+		add(0, login)
+	}
+	for _, user := range pr.Users {
+		add(user.ID, user.Login)
+		for _, login := range user.Logins {
+			add(0, login)
+		}
+	}
+
+	var erased []ErasedIdentity
+	err := tx.Where("value IN (?)", values).Find(&erased).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(erased) == 0 {
+		return nil, nil
+	}
+	dbErased := make([]database.ErasedIdentity, 0, len(erased))
+	for i := range erased {
+		dbErased = append(dbErased, DecodeErasedIdentity(&erased[i]))
+	}
+	return database.NewErasures(dbErased), nil
+}
+
+// ErasedIdentities returns the identities of all purged and pseudonymized
+// users ordered by type and value.
+//
+// ErasedIdentities satisfies the database interface.
+func (c *cockroachdb) ErasedIdentities(ctx context.Context) ([]database.ErasedIdentity, error) {
+	log.Debugf("ErasedIdentities")
+
+	tx := c.beginTx(ctx)
+	defer tx.Rollback()
+
+	var erased []ErasedIdentity
+	err := tx.Order("type, value").Find(&erased).Error
+	if err != nil {
+		return nil, err
+	}
+
+	dbErased := make([]database.ErasedIdentity, 0, len(erased))
+	for i := range erased {
+		dbErased = append(dbErased, DecodeErasedIdentity(&erased[i]))
+	}
+	return dbErased, nil
+}
+
+// RestoreErasedIdentity records the passed erased identity unless it was
+// already recorded.
+//
+// RestoreErasedIdentity satisfies the database interface.
+func (c *cockroachdb) RestoreErasedIdentity(ctx context.Context, dbErased *database.ErasedIdentity) error {
+	erased := EncodeErasedIdentity(dbErased)
+
+	log.Debugf("RestoreErasedIdentity: %v", erased.Type)
+
+	tx := c.beginTx(ctx)
+	err := tx.
+		Where(ErasedIdentity{Type: erased.Type, Value: erased.Value}).
+		Attrs(erased).
+		FirstOrCreate(&ErasedIdentity{}).
+		Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// rollupIdentityQuery returns the condition and arguments that match the
+// rollups of the identity: those of its accounts and those of its logins and
+// emails without an account.
+func rollupIdentityQuery(identity *database.UserIdentity, ids []int64) (string, []interface{}) {
+	authors := append(append([]string{}, identity.Logins...),
+		identity.Emails...)
+	return "user_id IN (?) OR (user_id = 0 AND author IN (?))",
+		[]interface{}{ids, authors}
+}
+
+// snapshotNames returns the names the snapshots of the identity may be stored
+// under.
+func snapshotNames(identity *database.UserIdentity) []string {
+	if identity.Contributor == "" {
+		return identity.Logins
+	}
+	return append([]string{identity.Contributor}, identity.Logins...)
+}
+
+// deleteIdentityAliases removes the aliases of the identity along with all
+// other aliases of its contributor.
+//
+// This function must be called within a transaction.
+func deleteIdentityAliases(tx *gorm.DB, identity *database.UserIdentity) error {
+	if identity.Contributor != "" {
+		err := tx.
+			Where("contributor = ?", identity.Contributor).
+			Delete(Alias{}).
+			Error
+		if err != nil {
+			return err
+		}
+	}
+	err := tx.
+		Where("type = ? AND value IN (?)", database.AliasLogin,
+			identity.Logins).
+		Delete(Alias{}).
+		Error
+	if err != nil {
+		return err
+	}
+	if len(identity.Emails) == 0 {
+		return nil
+	}
+	return tx.
+		Where("type = ? AND value IN (?)", database.AliasEmail,
+			identity.Emails).
+		Delete(Alias{}).
+		Error
+}
+
+// PurgeUser removes all data tied to the passed identity: the pull requests it
+// authored along with their commits, reviews and history, its reviews and
+// commits on other pull requests, its accounts, aliases, rollups and
+// snapshots.  Rollups of other users are updated for the removed pull
+// requests.  The erasure is recorded so that the identity is not stored
+// again.  ErrInvalidIdentity is returned when the identity has no logins or
+// empty ones.
+//
+// PurgeUser satisfies the database interface.
+func (c *cockroachdb) PurgeUser(ctx context.Context, identity database.UserIdentity) error {
+	log.Debugf("PurgeUser: %v", identity.Logins)
+
+	err := validateIdentity(&identity)
+	if err != nil {
+		return err
+	}
+
+	tx := c.beginTx(ctx)
+	err = purgeUser(tx, &identity)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// purgeUser removes all data tied to the passed identity.
+//
+// This function must be called within a transaction.
+func purgeUser(tx *gorm.DB, identity *database.UserIdentity) error {
+	logins := identity.Logins
+	ids, err := identityUserIDs(tx, identity)
+	if err != nil {
+		return err
+	}
+
+	// Remove the authored pull requests, which cascades to their child
+	// rows, after removing their contribution to the rollups of other
+	// users.
+	var prs []PullRequest
+	err = tx.
+		Preload("Commits").
+		Preload("Reviews").
+		Where("author IN (?)", logins).
+		Find(&prs).
+		Error
+	if err != nil {
+		return err
+	}
+	for i := range prs {
+		pr := &prs[i]
+		err := updateRollups(tx, pr, nil)
+		if err != nil {
+			return err
+		}
+		err = tx.
+			Where("repo_id = ? AND number = ?", pr.RepoID, pr.Number).
+			Delete(Change{}).
+			Error
+		if err != nil {
+			return err
+		}
+		err = tx.
+			Where("repo_id = ? AND number = ?", pr.RepoID, pr.Number).
+			Delete(PullRequest{}).
+			Error
+		if err != nil {
+			return err
+		}
+	}
+
+	// Remove the reviews and commits on the pull requests of others.
+	rollupWhere, rollupArgs := rollupIdentityQuery(identity, ids)
+	deletes := []struct {
+		model interface{}
+		where string
+		args  []interface{}
+	}{
+		{PullRequestReview{}, "author IN (?)", []interface{}{logins}},
+		{Commit{}, "author IN (?)", []interface{}{logins}},
+		{RequestedReviewer{}, "login IN (?)", []interface{}{logins}},
+		{Rollup{}, rollupWhere, rollupArgs},
+		{Snapshot{}, "login IN (?)", []interface{}{snapshotNames(identity)}},
+		{Change{}, "old IN (?) OR new IN (?)", []interface{}{logins, logins}},
+	}
+	if len(identity.Emails) > 0 {
+		deletes = append(deletes, struct {
+			model interface{}
+			where string
+			args  []interface{}
+		}{Commit{}, "author_email IN (?)", []interface{}{identity.Emails}})
+	}
+	if len(ids) > 0 {
+		deletes = append(deletes, struct {
+			model interface{}
+			where string
+			args  []interface{}
+		}{User{}, "id IN (?)", []interface{}{ids}})
+	}
+	for _, d := range deletes {
+		err := tx.Where(d.where, d.args...).Delete(d.model).Error
+		if err != nil {
+			return err
+		}
+	}
+	err = tx.
+		Model(&PullRequest{}).
+		Where("merged_by IN (?)", logins).
+		Update("merged_by", "").
+		Error
+	if err != nil {
+		return err
+	}
+	err = tx.
+		Model(&Commit{}).
+		Where("committer IN (?)", logins).
+		Updates(map[string]interface{}{"committer": "", "committer_id": 0}).
+		Error
+	if err != nil {
+		return err
+	}
+
+	err = deleteIdentityAliases(tx, identity)
+	if err != nil {
+		return err
+	}
+	return recordErasure(tx, identity, ids, "")
+}
+
+// PseudonymizeUser replaces every login of the passed identity with the passed
+// pseudonym and clears its account IDs and commit emails, so that the stats of
+// the user are kept without identifying them.  The accounts, aliases,
+// requested reviews and snapshots of the identity are removed and its rollups
+// are merged under the pseudonym.  The erasure is recorded so that the
+// identity is replaced by the same pseudonym when it is stored again.
+// ErrInvalidIdentity is returned when the identity has no logins or empty
+// ones, or when the pseudonym is empty.
+//
+// PseudonymizeUser satisfies the database interface.
+func (c *cockroachdb) PseudonymizeUser(ctx context.Context, identity database.UserIdentity, pseudonym string) error {
+	log.Debugf("PseudonymizeUser: %v", identity.Logins)
+
+	err := validateIdentity(&identity)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(pseudonym) == "" {
+		return database.ErrInvalidIdentity
+	}
+
+	tx := c.beginTx(ctx)
+	err = pseudonymizeUser(tx, &identity, pseudonym)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// pseudonymizeUser replaces the passed identity with the pseudonym.
+//
+// This function must be called within a transaction.
+func pseudonymizeUser(tx *gorm.DB, identity *database.UserIdentity, pseudonym string) error {
+	logins := identity.Logins
+	ids, err := identityUserIDs(tx, identity)
+	if err != nil {
+		return err
+	}
+
+	updates := []struct {
+		model  interface{}
+		where  string
+		args   []interface{}
+		values map[string]interface{}
+	}{
+		{&PullRequest{}, "author IN (?)", []interface{}{logins},
+			map[string]interface{}{"author": pseudonym, "author_id": 0}},
+		{&PullRequest{}, "merged_by IN (?)", []interface{}{logins},
+			map[string]interface{}{"merged_by": pseudonym}},
+		{&PullRequestReview{}, "author IN (?)", []interface{}{logins},
+			map[string]interface{}{"author": pseudonym, "author_id": 0}},
+		{&Commit{}, "author IN (?)", []interface{}{logins},
+			map[string]interface{}{"author": pseudonym, "author_id": 0,
+				"author_email": ""}},
+		{&Commit{}, "committer IN (?)", []interface{}{logins},
+			map[string]interface{}{"committer": pseudonym,
+				"committer_id": 0}},
+		{&Change{}, "old IN (?)", []interface{}{logins},
+			map[string]interface{}{"old": pseudonym}},
+		{&Change{}, "new IN (?)", []interface{}{logins},
+			map[string]interface{}{"new": pseudonym}},
+	}
+	if len(identity.Emails) > 0 {
+		updates = append(updates, struct {
+			model  interface{}
+			where  string
+			args   []interface{}
+			values map[string]interface{}
+		}{&Commit{}, "author_email IN (?)",
+			[]interface{}{identity.Emails},
+			map[string]interface{}{"author": pseudonym, "author_id": 0,
+				"author_email": ""}})
+	}
+	for _, u := range updates {
+		err := tx.
+			Model(u.model).
+			Where(u.where, u.args...).
+			Updates(u.values).
+			Error
+		if err != nil {
+			return err
+		}
+	}
+
+	// Merge the rollups of all accounts, logins and emails under the
+	// pseudonym.  Sums are cast since they are wider than the summed
+	// columns.
+	rollupWhere, rollupArgs := rollupIdentityQuery(identity, ids)
+	sums := make([]string, 0, len(rollupCounters))
+	for _, c := range rollupCounters {
+		sums = append(sums, "CAST(SUM("+c+") AS BIGINT)")
+	}
+	query := "INSERT INTO " + tableNameRollups + " (user_id, author, " +
+		"repo_id, month, " + strings.Join(rollupCounters, ", ") +
+		") SELECT 0, ?, repo_id, month, " + strings.Join(sums, ", ") +
+		" FROM " + tableNameRollups + " WHERE " + rollupWhere +
+		" GROUP BY repo_id, month"
+	err = execStatement(tx, tableNameRollups, query,
+		append([]interface{}{pseudonym}, rollupArgs...)...).Error
+	if err != nil {
+		return err
+	}
+
+	deletes := []struct {
+		model interface{}
+		where string
+		args  []interface{}
+	}{
+		{Rollup{}, rollupWhere, rollupArgs},
+		{RequestedReviewer{}, "login IN (?)", []interface{}{logins}},
+		{Snapshot{}, "login IN (?)", []interface{}{snapshotNames(identity)}},
+	}
+	if len(ids) > 0 {
+		deletes = append(deletes, struct {
+			model interface{}
+			where string
+			args  []interface{}
+		}{User{}, "id IN (?)", []interface{}{ids}})
+	}
+	for _, d := range deletes {
+		err := tx.Where(d.where, d.args...).Delete(d.model).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	err = deleteIdentityAliases(tx, identity)
+	if err != nil {
+		return err
+	}
+	return recordErasure(tx, identity, ids, pseudonym)
+}
+
+// PurgeCommitMessages drops the body of the messages of all commits authored
+// before the passed UNIX timestamp, keeping only their subject line, and
+// clears the commit messages recorded in the change history of those commits.
+// Line counts are not modified.  The number of commits whose message was
+// shortened is returned.
+//
+// PurgeCommitMessages satisfies the database interface.
+func (c *cockroachdb) PurgeCommitMessages(ctx context.Context, before int64) (int64, error) {
+	log.Debugf("PurgeCommitMessages: %v", before)
+
+	tx := c.beginTx(ctx)
+	res := tx.
+		Model(&Commit{}).
+		Where("authored_at < ? AND strpos(message, chr(10)) > 0", before).
+		Update("message", gorm.Expr("split_part(message, chr(10), 1)"))
+	if res.Error != nil {
+		tx.Rollback()
+		return 0, res.Error
+	}
+	err := tx.
+		Model(&Change{}).
+		Where("record = ? AND field = ? AND record_id IN (?)",
+			database.ChangeRecordCommit, "message",
+			tx.Model(&Commit{}).
+				Select("sha").
+				Where("authored_at < ?", before).
+				SubQuery()).
+		Updates(map[string]interface{}{"old": "", "new": ""}).
+		Error
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return res.RowsAffected, tx.Commit().Error
+}
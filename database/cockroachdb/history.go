@@ -0,0 +1,252 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package cockroachdb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/decred/github-tracker/database"
+	"github.com/jinzhu/gorm"
+)
+
+// storedPullRequest returns the stored version of the passed pull request
+// along with its children, or nil when it is not stored yet.
+//
+// This function must be called within a transaction.
+func storedPullRequest(tx *gorm.DB, pr *PullRequest) (*PullRequest, error) {
+	var old PullRequest
+	err := tx.
+		Preload("Commits").
+		Preload("Reviews").
+		Preload("Labels").
+		Preload("RequestedReviewers").
+		Where("repo_id = ? AND number = ?", pr.RepoID, pr.Number).
+		First(&old).
+		Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &old, nil
+}
+
+// recordChanges appends the changes between the stored pull request, along
+// with its commits and reviews, and the passed pull request to the change
+// history.  Nothing is recorded when the pull request is not stored yet.
+//
+// This function must be called within a transaction.
+func recordChanges(tx *gorm.DB, old, pr *PullRequest, changedAt int64) error {
+	if old == nil {
+		return nil
+	}
+
+	base := Change{
+		RepoID:         pr.RepoID,
+		Number:         pr.Number,
+		PullRequestURL: pr.URL,
+		ChangedAt:      changedAt,
+	}
+
+	// Pull request fields, including its labels and requested
+	// reviewers.
+	changes := diffFields(tx, base, database.ChangeRecordPullRequest, "",
+		old, pr)
+	oldLabels := make([]string, 0, len(old.Labels))
+	for _, label := range old.Labels {
+		oldLabels = append(oldLabels, label.Name)
+	}
+	newLabels := make([]string, 0, len(pr.Labels))
+	for _, label := range pr.Labels {
+		newLabels = append(newLabels, label.Name)
+	}
+	changes = appendSetChange(changes, base, "labels", oldLabels, newLabels)
+	oldReviewers := make([]string, 0, len(old.RequestedReviewers))
+	for _, reviewer := range old.RequestedReviewers {
+		oldReviewers = append(oldReviewers, reviewer.Login)
+	}
+	newReviewers := make([]string, 0, len(pr.RequestedReviewers))
+	for _, reviewer := range pr.RequestedReviewers {
+		newReviewers = append(newReviewers, reviewer.Login)
+	}
+	changes = appendSetChange(changes, base, "requested_reviewers",
+		oldReviewers, newReviewers)
+
+	// Commits by SHA.
+	oldCommits := make(map[string]*Commit, len(old.Commits))
+	for i := range old.Commits {
+		oldCommits[old.Commits[i].SHA] = &old.Commits[i]
+	}
+	for i := range pr.Commits {
+		commit := &pr.Commits[i]
+		oldCommit, ok := oldCommits[commit.SHA]
+		if !ok {
+			changes = append(changes, recordChange(base,
+				database.ChangeRecordCommit, commit.SHA,
+				database.ChangeCreate))
+			continue
+		}
+		delete(oldCommits, commit.SHA)
+		changes = append(changes, diffFields(tx, base,
+			database.ChangeRecordCommit, commit.SHA, oldCommit,
+			commit)...)
+	}
+	for _, commit := range old.Commits {
+		if _, ok := oldCommits[commit.SHA]; ok {
+			changes = append(changes, recordChange(base,
+				database.ChangeRecordCommit, commit.SHA,
+				database.ChangeDelete))
+		}
+	}
+
+	// Reviews by ID.
+	oldReviews := make(map[int64]*PullRequestReview, len(old.Reviews))
+	for i := range old.Reviews {
+		oldReviews[old.Reviews[i].ID] = &old.Reviews[i]
+	}
+	for i := range pr.Reviews {
+		review := &pr.Reviews[i]
+		id := strconv.FormatInt(review.ID, 10)
+		oldReview, ok := oldReviews[review.ID]
+		if !ok {
+			changes = append(changes, recordChange(base,
+				database.ChangeRecordReview, id,
+				database.ChangeCreate))
+			continue
+		}
+		delete(oldReviews, review.ID)
+		changes = append(changes, diffFields(tx, base,
+			database.ChangeRecordReview, id, oldReview, review)...)
+	}
+	for _, review := range old.Reviews {
+		if _, ok := oldReviews[review.ID]; ok {
+			changes = append(changes, recordChange(base,
+				database.ChangeRecordReview,
+				strconv.FormatInt(review.ID, 10),
+				database.ChangeDelete))
+		}
+	}
+
+	for _, change := range changes {
+		err := tx.Create(&change).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordChange returns a change of the passed record with the passed action.
+func recordChange(base Change, record, recordID, action string) Change {
+	change := base
+	change.Record = record
+	change.RecordID = recordID
+	change.Action = action
+	return change
+}
+
+// diffFields returns an update change for every column that differs between
+// the passed models, which must be of the same type.  Associations and the
+// pull request URL of child records, which follows the parent, are skipped.
+func diffFields(tx *gorm.DB, base Change, record, recordID string, old, new interface{}) []Change {
+	oldFields := tx.NewScope(old).Fields()
+	newFields := tx.NewScope(new).Fields()
+
+	var changes []Change
+	for i, field := range newFields {
+		if field.IsIgnored || field.Relationship != nil {
+			continue
+		}
+		if record != database.ChangeRecordPullRequest &&
+			field.DBName == "pull_request_url" {
+			continue
+		}
+		oldValue := fmt.Sprint(oldFields[i].Field.Interface())
+		newValue := fmt.Sprint(field.Field.Interface())
+		if oldValue == newValue {
+			continue
+		}
+		change := recordChange(base, record, recordID,
+			database.ChangeUpdate)
+		change.Field = field.DBName
+		change.Old = oldValue
+		change.New = newValue
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// appendSetChange appends an update change of the passed pull request field
+// when the passed sets of values differ.  The order of the values is not
+// significant.
+func appendSetChange(changes []Change, base Change, field string, old, new []string) []Change {
+	sort.Strings(old)
+	sort.Strings(new)
+	oldValue := strings.Join(old, ",")
+	newValue := strings.Join(new, ",")
+	if oldValue == newValue {
+		return changes
+	}
+	change := recordChange(base, database.ChangeRecordPullRequest, "",
+		database.ChangeUpdate)
+	change.Field = field
+	change.Old = oldValue
+	change.New = newValue
+	return append(changes, change)
+}
+
+// PullRequestHistory returns the changes that sync applied to the pull request
+// with the passed URL in the order they were applied.  The URL the pull request
+// had before its repository was renamed may be used as well.
+//
+// PullRequestHistory satisfies the database interface.
+func (c *cockroachdb) PullRequestHistory(ctx context.Context, url string) ([]database.Change, error) {
+	log.Debugf("PullRequestHistory: %v", url)
+
+	tx := c.beginTx(ctx)
+	defer tx.Rollback()
+
+	var pr PullRequest
+	err := tx.
+		Select("repo_id, number").
+		Where("url = ?", url).
+		First(&pr).
+		Error
+	if err == gorm.ErrRecordNotFound {
+		var change Change
+		err = tx.
+			Where("pull_request_url = ?", url).
+			First(&change).
+			Error
+		pr.RepoID = change.RepoID
+		pr.Number = change.Number
+	}
+	if err == gorm.ErrRecordNotFound {
+		return nil, database.ErrNoPullRequestFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	err = tx.
+		Where("repo_id = ? AND number = ?", pr.RepoID, pr.Number).
+		Order("id").
+		Find(&changes).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	dbChanges := make([]database.Change, 0, len(changes))
+	for _, change := range changes {
+		dbChanges = append(dbChanges, DecodeChange(&change))
+	}
+	return dbChanges, nil
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package cockroachdb
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/decred/github-tracker/database"
+	"github.com/jinzhu/gorm"
+)
+
+// nopSQL is a connection that is never used.  Model metadata does not require
+// a database.
+type nopSQL struct {
+	gorm.SQLCommon
+}
+
+func TestDiffFields(t *testing.T) {
+	db, err := gorm.Open("postgres", nopSQL{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := Change{
+		RepoID:         1,
+		Number:         2,
+		PullRequestURL: "https://github.com/decred/dcrd/pull/2",
+		ChangedAt:      1588291200,
+	}
+	change := func(record, recordID, field, old, new string) Change {
+		c := recordChange(base, record, recordID, database.ChangeUpdate)
+		c.Field = field
+		c.Old = old
+		c.New = new
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		record   string
+		recordID string
+		old      interface{}
+		new      interface{}
+		want     []Change
+	}{
+		{
+			name:   "pull request unchanged",
+			record: database.ChangeRecordPullRequest,
+			old: &PullRequest{
+				Title:  "multi: Fix",
+				Labels: []Label{{Name: "bug"}},
+			},
+			new: &PullRequest{
+				Title:  "multi: Fix",
+				Labels: []Label{{Name: "enhancement"}},
+			},
+		},
+		{
+			name:   "pull request",
+			record: database.ChangeRecordPullRequest,
+			old: &PullRequest{
+				URL:   "https://github.com/decred/dcrd/pull/2",
+				Title: "multi: Fix",
+				State: "open",
+			},
+			new: &PullRequest{
+				URL:    "https://github.com/decred/dcrd/pull/3",
+				Title:  "multi: Fix things",
+				State:  "closed",
+				Merged: true,
+			},
+			want: []Change{
+				change(database.ChangeRecordPullRequest, "", "url",
+					"https://github.com/decred/dcrd/pull/2",
+					"https://github.com/decred/dcrd/pull/3"),
+				change(database.ChangeRecordPullRequest, "", "title",
+					"multi: Fix", "multi: Fix things"),
+				change(database.ChangeRecordPullRequest, "", "merged",
+					"false", "true"),
+				change(database.ChangeRecordPullRequest, "", "state",
+					"open", "closed"),
+			},
+		},
+		{
+			name:     "review",
+			record:   database.ChangeRecordReview,
+			recordID: "10",
+			old: &PullRequestReview{
+				PullRequestURL: "https://github.com/decred/dcrd/pull/2",
+				ID:             10,
+				State:          "COMMENTED",
+			},
+			new: &PullRequestReview{
+				PullRequestURL: "https://github.com/decred/dcrd/pull/3",
+				ID:             10,
+				State:          "APPROVED",
+			},
+			want: []Change{
+				change(database.ChangeRecordReview, "10", "state",
+					"COMMENTED", "APPROVED"),
+			},
+		},
+		{
+			name:     "commit",
+			record:   database.ChangeRecordCommit,
+			recordID: "abc",
+			old: &Commit{
+				SHA:       "abc",
+				Additions: 1,
+			},
+			new: &Commit{
+				SHA:       "abc",
+				Additions: 2,
+				Deletions: 3,
+			},
+			want: []Change{
+				change(database.ChangeRecordCommit, "abc", "additions",
+					"1", "2"),
+				change(database.ChangeRecordCommit, "abc", "deletions",
+					"0", "3"),
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := diffFields(db, base, test.record, test.recordID,
+				test.old, test.new)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package cockroachdb
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// queryStartKey is the scope setting holding the time a query started.
+const queryStartKey = "metrics:query_start"
+
+var querySeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "githubtracker",
+	Subsystem: "db",
+	Name:      "query_duration_seconds",
+	Help:      "Latency of database queries by operation and table.",
+	Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14),
+}, []string{"operation", "table"})
+
+// RegisterMetrics registers the Prometheus collectors of the package with r.
+func RegisterMetrics(r prometheus.Registerer) error {
+	return r.Register(querySeconds)
+}
+
+// instrumentQueries registers gorm callbacks that observe the latency of the
+// create, query, row query, update and delete statements of db.  gorm does
+// not run callbacks for statements run with Exec, see execStatement.
+func instrumentQueries(db *gorm.DB) {
+	start := func(scope *gorm.Scope) {
+		scope.Set(queryStartKey, time.Now())
+	}
+	observe := func(operation string) func(*gorm.Scope) {
+		return func(scope *gorm.Scope) {
+			v, ok := scope.Get(queryStartKey)
+			if !ok {
+				return
+			}
+			querySeconds.WithLabelValues(operation, scope.TableName()).
+				Observe(time.Since(v.(time.Time)).Seconds())
+		}
+	}
+
+	callbacks := db.Callback()
+	callbacks.Create().Before("gorm:create").Register("metrics:before_create", start)
+	callbacks.Create().After("gorm:create").Register("metrics:after_create", observe("create"))
+	callbacks.Query().Before("gorm:query").Register("metrics:before_query", start)
+	callbacks.Query().After("gorm:query").Register("metrics:after_query", observe("query"))
+	callbacks.RowQuery().Before("gorm:row_query").Register("metrics:before_row_query", start)
+	callbacks.RowQuery().After("gorm:row_query").Register("metrics:after_row_query", observe("row_query"))
+	callbacks.Update().Before("gorm:update").Register("metrics:before_update", start)
+	callbacks.Update().After("gorm:update").Register("metrics:after_update", observe("update"))
+	callbacks.Delete().Before("gorm:delete").Register("metrics:before_delete", start)
+	callbacks.Delete().After("gorm:delete").Register("metrics:after_delete", observe("delete"))
+}
+
+// execStatement runs the passed raw SQL statement on tx and observes its
+// latency as an exec operation on the passed table.
+func execStatement(tx *gorm.DB, table, sql string, values ...interface{}) *gorm.DB {
+	start := time.Now()
+	res := tx.Exec(sql, values...)
+	querySeconds.WithLabelValues("exec", table).
+		Observe(time.Since(start).Seconds())
+	return res
+}
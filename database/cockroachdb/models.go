@@ -21,12 +21,70 @@ func (Version) TableName() string {
 	return tableNameVersions
 }
 
+// Organization is a GitHub organization or user account that owns
+// repositories.
+type Organization struct {
+	ID       int64  `gorm:"primary_key;auto_increment:false"`
+	Login    string `gorm:"not null;index"`
+	LastSync int64  `gorm:"not null"`
+}
+
+func (Organization) TableName() string {
+	return tableNameOrganization
+}
+
+// Repository is a GitHub repository keyed by its numeric ID.  Name and
+// FullName are the most recently seen names of the repository.
+type Repository struct {
+	ID             int64  `gorm:"primary_key;auto_increment:false"`
+	OrganizationID int64  `gorm:"not null;index"`
+	Name           string `gorm:"not null"`
+	FullName       string `gorm:"not null;index"`
+	DefaultBranch  string `gorm:"not null"`
+	Fork           bool   `gorm:"not null"`
+	Archived       bool   `gorm:"not null"`
+	Private        bool   `gorm:"not null"`
+	LastSync       int64  `gorm:"not null"`
+
+	Names []RepositoryName `gorm:"foreignkey:RepositoryID"`
+}
+
+func (Repository) TableName() string {
+	return tableNameRepositories
+}
+
+// RepositoryName records a full name that was used by a repository along with
+// the time range over which it was seen by sync.
+type RepositoryName struct {
+	RepositoryID int64  `gorm:"primary_key;auto_increment:false"`
+	FullName     string `gorm:"primary_key"`
+	FirstSeen    int64  `gorm:"not null"`
+	LastSeen     int64  `gorm:"not null"`
+}
+
+func (RepositoryName) TableName() string {
+	return tableNameRepositoryNames
+}
+
+// PullRequest is keyed by the ID of its repository and its number so that
+// renaming or transferring the repository does not create duplicates.  The URL
+// is unique as well and is referenced by the child tables.
 type PullRequest struct {
+	RepoID       int64  `gorm:"primary_key;auto_increment:false"`
+	Number       int    `gorm:"primary_key;auto_increment:false"`
 	Repo         string `gorm:"not null"`
 	Organization string `gorm:"not null"`
-	URL          string `gorm:"primary_key"`
-	Number       int    `gorm:"not null"`
+	URL          string `gorm:"not null;unique_index"`
 	Author       string `gorm:"not null"`
+	AuthorID     int64  `gorm:"not null;index"`
+	Title        string `gorm:"not null"`
+	Body         string `gorm:"not null"`
+	BaseRef      string `gorm:"not null"`
+	HeadRef      string `gorm:"not null"`
+	HeadRepo     string `gorm:"not null"`
+	Fork         bool   `gorm:"not null"`
+	Draft        bool   `gorm:"not null"`
+	CreatedAt    int64  `gorm:"not null"`
 	UpdatedAt    int64  `gorm:"not null"`
 	ClosedAt     int64  `gorm:"not null"`
 	MergedAt     int64  `gorm:"not null"`
@@ -35,9 +93,12 @@ type PullRequest struct {
 	Additions    int    `gorm:"not null"`
 	Deletions    int    `gorm:"not null"`
 	MergedBy     string `gorm:"not null"`
+	Milestone    string `gorm:"not null"`
 
-	Commits []Commit            `gorm:"foreignkey:PullRequestURL"`
-	Reviews []PullRequestReview `gorm:"foreignkey:PullRequestURL"`
+	Commits            []Commit            `gorm:"foreignkey:PullRequestURL;association_foreignkey:URL"`
+	Reviews            []PullRequestReview `gorm:"foreignkey:PullRequestURL;association_foreignkey:URL"`
+	Labels             []Label             `gorm:"foreignkey:PullRequestURL;association_foreignkey:URL"`
+	RequestedReviewers []RequestedReviewer `gorm:"foreignkey:PullRequestURL;association_foreignkey:URL"`
 }
 
 // TableName returns the table name of the invoices table.
@@ -48,8 +109,12 @@ func (PullRequest) TableName() string {
 type Commit struct {
 	PullRequestURL string `gorm:"primary_key"`
 	Author         string `gorm:"not null"`
+	AuthorID       int64  `gorm:"not null;index"`
+	AuthorEmail    string `gorm:"not null;index"`
+	AuthoredAt     int64  `gorm:"not null;index"`
 	Committer      string `gorm:"not null"`
-	SHA            string `gorm:"not null"`
+	CommitterID    int64  `gorm:"not null"`
+	SHA            string `gorm:"primary_key"`
 	URL            string `gorm:"not null"`
 	Message        string `gorm:"not null"`
 	Additions      int    `gorm:"not null"`
@@ -61,9 +126,10 @@ func (Commit) TableName() string {
 }
 
 type PullRequestReview struct {
-	PullRequestURL string `gorm:"not null"`
+	PullRequestURL string `gorm:"not null;index"`
 	ID             int64  `gorm:"primary_key"`
 	Author         string `gorm:"not null"`
+	AuthorID       int64  `gorm:"not null;index"`
 	State          string `gorm:"not null"`
 	SubmittedAt    int64  `gorm:"not null"`
 	CommitID       string `gorm:"not null"`
@@ -74,3 +140,157 @@ type PullRequestReview struct {
 func (PullRequestReview) TableName() string {
 	return tableNameReviews
 }
+
+// Label is a label that is applied to a pull request.
+type Label struct {
+	PullRequestURL string `gorm:"primary_key"`
+	Name           string `gorm:"primary_key"`
+}
+
+func (Label) TableName() string {
+	return tableNameLabels
+}
+
+// RequestedReviewer is a user whose review was requested on a pull request.
+type RequestedReviewer struct {
+	PullRequestURL string `gorm:"primary_key"`
+	Login          string `gorm:"primary_key"`
+}
+
+func (RequestedReviewer) TableName() string {
+	return tableNameRequestedReviewers
+}
+
+// User is a GitHub account keyed by its numeric ID.  Login is the most
+// recently seen login of the account.
+type User struct {
+	ID       int64  `gorm:"primary_key;auto_increment:false"`
+	NodeID   string `gorm:"not null"`
+	Login    string `gorm:"not null;index"`
+	Type     string `gorm:"not null"`
+	LastSeen int64  `gorm:"not null"`
+
+	Logins []UserLogin `gorm:"foreignkey:UserID"`
+}
+
+func (User) TableName() string {
+	return tableNameUsers
+}
+
+// UserLogin records a login that was used by a GitHub account along with the
+// time range over which it was seen by sync.
+type UserLogin struct {
+	UserID    int64  `gorm:"primary_key;auto_increment:false"`
+	Login     string `gorm:"primary_key"`
+	FirstSeen int64  `gorm:"not null"`
+	LastSeen  int64  `gorm:"not null"`
+}
+
+func (UserLogin) TableName() string {
+	return tableNameUserLogins
+}
+
+// Alias assigns a login or commit author email to a canonical contributor.
+// An alias may only be assigned to a single contributor.
+type Alias struct {
+	Type        string `gorm:"primary_key"`
+	Value       string `gorm:"primary_key"`
+	Contributor string `gorm:"not null;index"`
+}
+
+func (Alias) TableName() string {
+	return tableNameAliases
+}
+
+// Change is an entry of the append-only history of a pull request.  Changes
+// are keyed by the repository ID and number of the pull request so that they
+// are not split by renames.
+type Change struct {
+	ID             int64  `gorm:"primary_key"`
+	RepoID         int64  `gorm:"not null;index:idx_changes_pull_request"`
+	Number         int    `gorm:"not null;index:idx_changes_pull_request"`
+	PullRequestURL string `gorm:"not null;index"`
+	Record         string `gorm:"not null"`
+	RecordID       string `gorm:"not null"`
+	Action         string `gorm:"not null"`
+	Field          string `gorm:"not null"`
+	Old            string `gorm:"not null"`
+	New            string `gorm:"not null"`
+	ChangedAt      int64  `gorm:"not null"`
+}
+
+func (Change) TableName() string {
+	return tableNameChanges
+}
+
+// Snapshot is the frozen user information of a contributor, or of a user
+// without aliases, for a month.  Login holds the name of the contributor or
+// the login of the user.  Snapshots are never updated once written.
+type Snapshot struct {
+	Organization string `gorm:"primary_key"`
+	Login        string `gorm:"primary_key"`
+	Year         int    `gorm:"primary_key;auto_increment:false"`
+	Month        int    `gorm:"primary_key;auto_increment:false"`
+	Payload      []byte `gorm:"not null"`
+	Hash         string `gorm:"not null"`
+	CreatedAt    int64  `gorm:"not null"`
+}
+
+func (Snapshot) TableName() string {
+	return tableNameSnapshots
+}
+
+// Rollup holds the monthly totals of a user in a repository.  Rows are updated
+// incrementally by the difference between the stored and the new version of a
+// pull request whenever one is upserted.  Rows are keyed by the ID of the
+// account of the user, with an empty Author, so that renames do not split
+// them.  Contributions without an account are keyed by a zero user ID and
+// their login or commit author email.
+type Rollup struct {
+	UserID            int64  `gorm:"primary_key;auto_increment:false"`
+	Author            string `gorm:"primary_key"`
+	RepoID            int64  `gorm:"primary_key;auto_increment:false"`
+	Month             int    `gorm:"primary_key;auto_increment:false;index"`
+	PullRequests      int64  `gorm:"not null"`
+	MergedAdditions   int64  `gorm:"not null"`
+	MergedDeletions   int64  `gorm:"not null"`
+	Reviews           int64  `gorm:"not null"`
+	ReviewedAdditions int64  `gorm:"not null"`
+	ReviewedDeletions int64  `gorm:"not null"`
+	Commits           int64  `gorm:"not null"`
+	CommitAdditions   int64  `gorm:"not null"`
+	CommitDeletions   int64  `gorm:"not null"`
+}
+
+func (Rollup) TableName() string {
+	return tableNameRollups
+}
+
+// APIKey is an API key that authenticates RPC callers.  Only the hash of the
+// key is stored.
+type APIKey struct {
+	ID         string `gorm:"primary_key"`
+	Name       string `gorm:"not null"`
+	Hash       string `gorm:"not null;unique_index"`
+	Scope      string `gorm:"not null"`
+	CreatedAt  int64  `gorm:"not null"`
+	ExpiresAt  int64  `gorm:"not null"`
+	LastUsedAt int64  `gorm:"not null"`
+}
+
+func (APIKey) TableName() string {
+	return tableNameAPIKeys
+}
+
+// ErasedIdentity records an account ID, login or commit email of a purged or
+// pseudonymized user.  Pseudonym is empty when the user was purged.
+type ErasedIdentity struct {
+	Type      string `gorm:"primary_key"`
+	Value     string `gorm:"primary_key"`
+	Pseudonym string `gorm:"not null"`
+	ErasedAt  int64  `gorm:"not null"`
+}
+
+func (ErasedIdentity) TableName() string {
+	return tableNameErasedIdentities
+}
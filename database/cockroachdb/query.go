@@ -0,0 +1,233 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package cockroachdb
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/decred/github-tracker/database"
+)
+
+const (
+	// defaultQueryLimit is the number of pull requests returned by a
+	// query when no limit is specified.
+	defaultQueryLimit = 100
+
+	// maxQueryLimit is the maximum number of pull requests returned by a
+	// single query.
+	maxQueryLimit = 1000
+)
+
+// sortColumn describes a column that pull request queries may be sorted by
+// along with the accessor used to build the cursor of a page.
+type sortColumn struct {
+	name  string
+	value func(*PullRequest) int64
+}
+
+var sortColumns = map[database.PullRequestSortField]sortColumn{
+	database.SortByUpdated: {
+		name:  "updated_at",
+		value: func(pr *PullRequest) int64 { return pr.UpdatedAt },
+	},
+	database.SortByMerged: {
+		name:  "merged_at",
+		value: func(pr *PullRequest) int64 { return pr.MergedAt },
+	},
+	database.SortByClosed: {
+		name:  "closed_at",
+		value: func(pr *PullRequest) int64 { return pr.ClosedAt },
+	},
+	database.SortByNumber: {
+		name:  "number",
+		value: func(pr *PullRequest) int64 { return int64(pr.Number) },
+	},
+	database.SortByCreated: {
+		name:  "created_at",
+		value: func(pr *PullRequest) int64 { return pr.CreatedAt },
+	},
+}
+
+// pullRequestCursor is the decoded form of the opaque cursor returned by
+// pull request queries.  It contains the sort value and URL of the last pull
+// request of a page.  The URL breaks ties between equal sort values.
+type pullRequestCursor struct {
+	Value int64  `json:"v"`
+	URL   string `json:"u"`
+}
+
+func encodeCursor(c pullRequestCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (*pullRequestCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, database.ErrInvalidCursor
+	}
+	var c pullRequestCursor
+	err = json.Unmarshal(b, &c)
+	if err != nil {
+		return nil, database.ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// queryLimit returns the number of pull requests a query with the passed
+// limit returns.  The default is used when the limit is not positive, and the
+// limit is capped at maxQueryLimit.
+func queryLimit(limit int) int {
+	switch {
+	case limit <= 0:
+		return defaultQueryLimit
+	case limit > maxQueryLimit:
+		return maxQueryLimit
+	}
+	return limit
+}
+
+// PullRequests returns a page of pull requests that match the passed query
+// along with the cursor of the next page.  An empty cursor is returned when
+// there are no more results.
+//
+// PullRequests satisfies the database interface.
+func (c *cockroachdb) PullRequests(ctx context.Context, q database.PullRequestQuery) ([]*database.PullRequest, string, error) {
+	log.Debugf("PullRequests: %+v", q)
+
+	column, ok := sortColumns[q.SortBy]
+	if !ok {
+		return nil, "", fmt.Errorf("invalid sort field %v", q.SortBy)
+	}
+	limit := queryLimit(q.Limit)
+
+	tx := c.beginTx(ctx)
+	defer tx.Rollback()
+
+	query := tx.Table(tableNamePullRequest)
+	if q.Organization != "" {
+		query = query.Where("LOWER(organization) = LOWER(?)", q.Organization)
+	}
+	if q.Repo != "" {
+		query = query.Where("LOWER(repo) = LOWER(?)", q.Repo)
+	}
+	if q.Author != "" {
+		query = query.Where("LOWER(author) = LOWER(?)", q.Author)
+	}
+	if q.State != "" {
+		query = query.Where("state = ?", q.State)
+	}
+	if q.Label != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM "+tableNameLabels+
+			" WHERE "+tableNameLabels+".pull_request_url = "+
+			tableNamePullRequest+".url AND "+
+			tableNameLabels+".name = ?)", q.Label)
+	}
+	ranges := []struct {
+		column        string
+		after, before int64
+	}{
+		{"merged_at", q.MergedAfter, q.MergedBefore},
+		{"closed_at", q.ClosedAfter, q.ClosedBefore},
+		{"updated_at", q.UpdatedAfter, q.UpdatedBefore},
+	}
+	for _, r := range ranges {
+		if r.after != 0 {
+			query = query.Where(r.column+" >= ?", r.after)
+		}
+		if r.before != 0 {
+			query = query.Where(r.column+" <= ?", r.before)
+		}
+	}
+
+	// Pages are selected using the sort value and URL of the last pull
+	// request of the previous page so that results remain stable while
+	// the table is being updated.
+	order, cmp := "DESC", "<"
+	if q.Ascending {
+		order, cmp = "ASC", ">"
+	}
+	if q.Cursor != "" {
+		cursor, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		query = query.Where(fmt.Sprintf("%[1]s %[2]s ? OR (%[1]s = ? AND url %[2]s ?)",
+			column.name, cmp), cursor.Value, cursor.Value, cursor.URL)
+	}
+
+	prs := make([]PullRequest, 0, limit+1)
+	err := query.
+		Preload("Labels").
+		Preload("RequestedReviewers").
+		Order(column.name + " " + order).
+		Order("url " + order).
+		Limit(limit + 1).
+		Find(&prs).
+		Error
+	if err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if len(prs) > limit {
+		prs = prs[:limit]
+		last := &prs[limit-1]
+		next = encodeCursor(pullRequestCursor{
+			Value: column.value(last),
+			URL:   last.URL,
+		})
+	}
+
+	dbPRs := make([]*database.PullRequest, 0, len(prs))
+	for _, vv := range prs {
+		dbPRs = append(dbPRs, DecodePullRequest(&vv))
+	}
+	return dbPRs, next, nil
+}
+
+// ForEachPullRequest calls the passed function for every pull request along
+// with its commits, reviews, labels and requested reviewers in URL order.
+// Pull requests are loaded in batches so that the whole table is never held in
+// memory.  Iteration stops at the first error returned by the function.
+//
+// ForEachPullRequest satisfies the database interface.
+func (c *cockroachdb) ForEachPullRequest(ctx context.Context, fn func(*database.PullRequest) error) error {
+	log.Debugf("ForEachPullRequest")
+
+	tx := c.beginTx(ctx)
+	defer tx.Rollback()
+
+	var last string
+	for {
+		prs := make([]PullRequest, 0, defaultQueryLimit)
+		err := tx.
+			Preload("Commits").
+			Preload("Reviews").
+			Preload("Labels").
+			Preload("RequestedReviewers").
+			Where("url > ?", last).
+			Order("url").
+			Limit(defaultQueryLimit).
+			Find(&prs).
+			Error
+		if err != nil {
+			return err
+		}
+		for i := range prs {
+			err := fn(DecodePullRequest(&prs[i]))
+			if err != nil {
+				return err
+			}
+		}
+		if len(prs) < defaultQueryLimit {
+			return nil
+		}
+		last = prs[len(prs)-1].URL
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package cockroachdb
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/decred/github-tracker/database"
+)
+
+func TestCursor(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor pullRequestCursor
+	}{
+		{
+			name:   "zero",
+			cursor: pullRequestCursor{},
+		},
+		{
+			name: "url",
+			cursor: pullRequestCursor{
+				Value: 1588291200,
+				URL:   "https://github.com/decred/dcrd/pull/2000",
+			},
+		},
+		{
+			name: "negative",
+			cursor: pullRequestCursor{
+				Value: -1,
+				URL:   "https://github.com/decred/dcrd/pull/1?a=b&c=d",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := decodeCursor(encodeCursor(test.cursor))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *got != test.cursor {
+				t.Fatalf("got %+v, want %+v", *got, test.cursor)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{
+			name:   "base64",
+			cursor: "not a cursor!",
+		},
+		{
+			name:   "padded",
+			cursor: base64.URLEncoding.EncodeToString([]byte(`{"v":1}`)),
+		},
+		{
+			name:   "json",
+			cursor: base64.RawURLEncoding.EncodeToString([]byte("{")),
+		},
+		{
+			name:   "type",
+			cursor: base64.RawURLEncoding.EncodeToString([]byte(`{"v":"1"}`)),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := decodeCursor(test.cursor)
+			if !errors.Is(err, database.ErrInvalidCursor) {
+				t.Fatalf("got %v, want %v", err, database.ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestQueryLimit(t *testing.T) {
+	tests := []struct {
+		limit int
+		want  int
+	}{
+		{limit: -1, want: defaultQueryLimit},
+		{limit: 0, want: defaultQueryLimit},
+		{limit: 1, want: 1},
+		{limit: maxQueryLimit, want: maxQueryLimit},
+		{limit: maxQueryLimit + 1, want: maxQueryLimit},
+	}
+	for _, test := range tests {
+		got := queryLimit(test.limit)
+		if got != test.want {
+			t.Errorf("queryLimit(%d): got %d, want %d", test.limit, got,
+				test.want)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package cockroachdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/decred/github-tracker/database"
+	"github.com/jinzhu/gorm"
+)
+
+// UpsertRepository creates or updates a repository along with the
+// organization that owns it and records the name it was seen with.  When the
+// repository was renamed or transferred, the names and URLs stored with its
+// pull requests, reviews and commits are updated so that queries by name and
+// URL keep matching.
+//
+// UpsertRepository satisfies the database interface.
+func (c *cockroachdb) UpsertRepository(ctx context.Context, dbRepo *database.Repository) error {
+	repo := EncodeRepository(dbRepo)
+	seen := time.Now().Unix()
+
+	log.Debugf("UpsertRepository: %v %v", repo.ID, repo.FullName)
+
+	tx := c.beginTx(ctx)
+	err := tx.
+		Where(Organization{ID: repo.OrganizationID}).
+		Assign(Organization{Login: dbRepo.Organization}).
+		FirstOrCreate(&Organization{}).
+		Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var existing Repository
+	err = tx.Where("id = ?", repo.ID).First(&existing).Error
+	switch {
+	case err == gorm.ErrRecordNotFound:
+		err = tx.Create(&repo).Error
+	case err != nil:
+	default:
+		repo.LastSync = existing.LastSync
+		err = tx.Save(&repo).Error
+		if err == nil && existing.FullName != repo.FullName {
+			log.Infof("Repository %v renamed to %v", existing.FullName,
+				repo.FullName)
+			err = renameRepository(tx, &existing, &repo,
+				dbRepo.Organization)
+		}
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.
+		Where(RepositoryName{RepositoryID: repo.ID, FullName: repo.FullName}).
+		Attrs(RepositoryName{FirstSeen: seen}).
+		Assign(RepositoryName{LastSeen: seen}).
+		FirstOrCreate(&RepositoryName{}).
+		Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// renameRepository updates the repository and organization names that are
+// stored with the pull requests, reviews and commits of the renamed
+// repository, along with their API URLs.  The new pull request URLs cascade to
+// the child rows.  The change history keeps the URLs of the time of each
+// change.
+//
+// This function must be called within a transaction.
+func renameRepository(tx *gorm.DB, old, repo *Repository, org string) error {
+	oldPath := "/repos/" + old.FullName + "/"
+	newPath := "/repos/" + repo.FullName + "/"
+	err := tx.
+		Model(&PullRequest{}).
+		Where("repo_id = ?", repo.ID).
+		Updates(map[string]interface{}{
+			"repo":         repo.Name,
+			"organization": org,
+			"url":          gorm.Expr("replace(url, ?, ?)", oldPath, newPath),
+		}).
+		Error
+	if err != nil {
+		return err
+	}
+	err = tx.
+		Model(&Commit{}).
+		Where("pull_request_url IN (SELECT url FROM "+
+			tableNamePullRequest+" WHERE repo_id = ?)", repo.ID).
+		Update("url", gorm.Expr("replace(url, ?, ?)", oldPath, newPath)).
+		Error
+	if err != nil {
+		return err
+	}
+	return tx.
+		Model(&PullRequestReview{}).
+		Where("pull_request_url IN (SELECT url FROM "+
+			tableNamePullRequest+" WHERE repo_id = ?)", repo.ID).
+		Update("repo", repo.Name).
+		Error
+}
+
+// restoreRepositoryNameQuery stores a repository name, widening the time span
+// of an existing one.
+const restoreRepositoryNameQuery = "INSERT INTO " + tableNameRepositoryNames +
+	" (repository_id, full_name, first_seen, last_seen) VALUES (?, ?, ?, ?)" +
+	" ON CONFLICT (repository_id, full_name) DO UPDATE SET" +
+	" first_seen = LEAST(" + tableNameRepositoryNames + ".first_seen," +
+	" excluded.first_seen)," +
+	" last_seen = GREATEST(" + tableNameRepositoryNames + ".last_seen," +
+	" excluded.last_seen)"
+
+// RestoreRepositoryName stores the passed repository name along with the time
+// span it was seen.  The time span of an existing name is widened to include
+// the passed one.
+//
+// RestoreRepositoryName satisfies the database interface.
+func (c *cockroachdb) RestoreRepositoryName(ctx context.Context, dbName *database.RepositoryName) error {
+	name := EncodeRepositoryName(dbName)
+
+	log.Debugf("RestoreRepositoryName: %v %v", name.RepositoryID,
+		name.FullName)
+
+	tx := c.beginTx(ctx)
+	err := execStatement(tx, tableNameRepositoryNames,
+		restoreRepositoryNameQuery, name.RepositoryID,
+		name.FullName, name.FirstSeen, name.LastSeen).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// RepositoryNames returns the name history of all repositories ordered by
+// repository ID and the time the names were first seen.
+//
+// RepositoryNames satisfies the database interface.
+func (c *cockroachdb) RepositoryNames(ctx context.Context) ([]database.RepositoryName, error) {
+	log.Debugf("RepositoryNames")
+
+	tx := c.beginTx(ctx)
+	defer tx.Rollback()
+
+	var names []RepositoryName
+	err := tx.Order("repository_id, first_seen").Find(&names).Error
+	if err != nil {
+		return nil, err
+	}
+
+	dbNames := make([]database.RepositoryName, 0, len(names))
+	for _, name := range names {
+		dbNames = append(dbNames, DecodeRepositoryName(&name))
+	}
+	return dbNames, nil
+}
+
+// RepositorySynced records the time the repository with the passed ID was last
+// synced.
+//
+// RepositorySynced satisfies the database interface.
+func (c *cockroachdb) RepositorySynced(ctx context.Context, repoID int64, syncedAt int64) error {
+	log.Debugf("RepositorySynced: %v", repoID)
+
+	tx := c.beginTx(ctx)
+	err := tx.
+		Model(&Repository{}).
+		Where("id = ?", repoID).
+		Update("last_sync", syncedAt).
+		Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// UpsertOrganization creates the passed organization or updates its login.
+// The last sync time of an existing organization is kept.
+//
+// UpsertOrganization satisfies the database interface.
+func (c *cockroachdb) UpsertOrganization(ctx context.Context, dbOrg *database.Organization) error {
+	log.Debugf("UpsertOrganization: %v %v", dbOrg.ID, dbOrg.Login)
+
+	tx := c.beginTx(ctx)
+	err := tx.
+		Where(Organization{ID: dbOrg.ID}).
+		Assign(Organization{Login: dbOrg.Login}).
+		FirstOrCreate(&Organization{}).
+		Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// OrganizationSynced records the time the organization with the passed ID was
+// last synced.
+//
+// OrganizationSynced satisfies the database interface.
+func (c *cockroachdb) OrganizationSynced(ctx context.Context, orgID int64, syncedAt int64) error {
+	log.Debugf("OrganizationSynced: %v", orgID)
+
+	tx := c.beginTx(ctx)
+	err := tx.
+		Model(&Organization{}).
+		Where("id = ?", orgID).
+		Update("last_sync", syncedAt).
+		Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// Organizations returns all organizations ordered by login.
+//
+// Organizations satisfies the database interface.
+func (c *cockroachdb) Organizations(ctx context.Context) ([]database.Organization, error) {
+	log.Debugf("Organizations")
+
+	tx := c.beginTx(ctx)
+	defer tx.Rollback()
+
+	var orgs []Organization
+	err := tx.Order("login").Find(&orgs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	dbOrgs := make([]database.Organization, 0, len(orgs))
+	for _, org := range orgs {
+		dbOrgs = append(dbOrgs, DecodeOrganization(&org))
+	}
+	return dbOrgs, nil
+}
+
+// Repositories returns all repositories along with their name history ordered
+// by full name.
+//
+// Repositories satisfies the database interface.
+func (c *cockroachdb) Repositories(ctx context.Context) ([]database.Repository, error) {
+	log.Debugf("Repositories")
+
+	tx := c.beginTx(ctx)
+	defer tx.Rollback()
+
+	var orgs []Organization
+	err := tx.Find(&orgs).Error
+	if err != nil {
+		return nil, err
+	}
+	orgLogins := make(map[int64]string, len(orgs))
+	for _, org := range orgs {
+		orgLogins[org.ID] = org.Login
+	}
+
+	var repos []Repository
+	err = tx.
+		Preload("Names", func(db *gorm.DB) *gorm.DB {
+			return db.Order("first_seen")
+		}).
+		Order("full_name").
+		Find(&repos).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	dbRepos := make([]database.Repository, 0, len(repos))
+	for _, repo := range repos {
+		dbRepo := DecodeRepository(&repo)
+		dbRepo.Organization = orgLogins[repo.OrganizationID]
+		dbRepos = append(dbRepos, *dbRepo)
+	}
+	return dbRepos, nil
+}
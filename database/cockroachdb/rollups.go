@@ -0,0 +1,253 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package cockroachdb
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/decred/github-tracker/database"
+	"github.com/jinzhu/gorm"
+)
+
+// rollupMonth returns the UTC calendar month of the passed UNIX timestamp as
+// YYYYMM.  The month spans the same range as the months of the user
+// information.
+func rollupMonth(timestamp int64) int {
+	t := time.Unix(timestamp, 0).UTC()
+	return t.Year()*100 + int(t.Month())
+}
+
+// rollupAuthor returns the user ID and author a contribution is rolled up
+// under.  Contributions are rolled up under the account of the contributor,
+// so that renames do not split them, and otherwise under its login, such as a
+// pseudonym, or its commit author email.
+func rollupAuthor(userID int64, login, email string) (int64, string) {
+	switch {
+	case userID != 0:
+		return userID, ""
+	case login != "":
+		return 0, login
+	default:
+		return 0, email
+	}
+}
+
+// rollupKey identifies a rollup row.
+type rollupKey struct {
+	userID int64
+	author string
+	repoID int64
+	month  int
+}
+
+// addRollups adds the contribution of the passed pull request along with its
+// reviews and commits, multiplied by sign, to the passed rollups.
+// Contributions without a user ID, login or email are not rolled up.
+func addRollups(rollups map[rollupKey]*Rollup, pr *PullRequest, sign int64) {
+	rollup := func(userID int64, login, email string, timestamp int64) *Rollup {
+		key := rollupKey{
+			repoID: pr.RepoID,
+			month:  rollupMonth(timestamp),
+		}
+		key.userID, key.author = rollupAuthor(userID, login, email)
+		if key.userID == 0 && key.author == "" {
+			return nil
+		}
+		r, ok := rollups[key]
+		if !ok {
+			r = &Rollup{
+				UserID: key.userID,
+				Author: key.author,
+				RepoID: key.repoID,
+				Month:  key.month,
+			}
+			rollups[key] = r
+		}
+		return r
+	}
+
+	if pr.Merged && pr.MergedAt != 0 {
+		if r := rollup(pr.AuthorID, pr.Author, "", pr.MergedAt); r != nil {
+			r.PullRequests += sign
+			r.MergedAdditions += sign * int64(pr.Additions)
+			r.MergedDeletions += sign * int64(pr.Deletions)
+		}
+	}
+	for _, review := range pr.Reviews {
+		if review.SubmittedAt == 0 {
+			continue
+		}
+		r := rollup(review.AuthorID, review.Author, "", review.SubmittedAt)
+		if r == nil {
+			continue
+		}
+		r.Reviews += sign
+		r.ReviewedAdditions += sign * int64(pr.Additions)
+		r.ReviewedDeletions += sign * int64(pr.Deletions)
+	}
+	for _, commit := range pr.Commits {
+		if commit.AuthoredAt == 0 {
+			continue
+		}
+		r := rollup(commit.AuthorID, commit.Author, commit.AuthorEmail,
+			commit.AuthoredAt)
+		if r == nil {
+			continue
+		}
+		r.Commits += sign
+		r.CommitAdditions += sign * int64(commit.Additions)
+		r.CommitDeletions += sign * int64(commit.Deletions)
+	}
+}
+
+// updateRollups applies the difference between the contributions of the
+// stored and the new version of a pull request to the rollups.  The stored
+// version is nil for new pull requests and the new version is nil for removed
+// pull requests.
+//
+// This function must be called within a transaction.
+func updateRollups(tx *gorm.DB, old, pr *PullRequest) error {
+	deltas := make(map[rollupKey]*Rollup)
+	if old != nil {
+		addRollups(deltas, old, -1)
+	}
+	if pr != nil {
+		addRollups(deltas, pr, 1)
+	}
+
+	// Apply the deltas in key order so that concurrent transactions lock
+	// the rows in the same order.
+	keys := make([]rollupKey, 0, len(deltas))
+	for key, delta := range deltas {
+		if *delta == (Rollup{UserID: key.userID, Author: key.author,
+			RepoID: key.repoID, Month: key.month}) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.userID != b.userID {
+			return a.userID < b.userID
+		}
+		if a.author != b.author {
+			return a.author < b.author
+		}
+		if a.repoID != b.repoID {
+			return a.repoID < b.repoID
+		}
+		return a.month < b.month
+	})
+
+	for _, key := range keys {
+		d := deltas[key]
+		err := execStatement(tx, tableNameRollups, upsertRollupQuery,
+			d.UserID, d.Author, d.RepoID, d.Month,
+			d.PullRequests, d.MergedAdditions, d.MergedDeletions,
+			d.Reviews, d.ReviewedAdditions, d.ReviewedDeletions,
+			d.Commits, d.CommitAdditions, d.CommitDeletions).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rollupCounters are the columns of the rollups table that hold totals.
+var rollupCounters = []string{
+	"pull_requests",
+	"merged_additions",
+	"merged_deletions",
+	"reviews",
+	"reviewed_additions",
+	"reviewed_deletions",
+	"commits",
+	"commit_additions",
+	"commit_deletions",
+}
+
+// upsertRollupQuery inserts a rollup row or adds to the totals of the existing
+// row.
+var upsertRollupQuery = func() string {
+	updates := make([]string, 0, len(rollupCounters))
+	for _, c := range rollupCounters {
+		updates = append(updates, c+" = "+tableNameRollups+"."+c+
+			" + excluded."+c)
+	}
+	return "INSERT INTO " + tableNameRollups + " (user_id, author, " +
+		"repo_id, month, " + strings.Join(rollupCounters, ", ") +
+		") VALUES (?, ?, ?, ?" + strings.Repeat(", ?", len(rollupCounters)) +
+		") ON CONFLICT (user_id, author, repo_id, month) DO UPDATE SET " +
+		strings.Join(updates, ", ")
+}()
+
+// Rollups returns the rollups that match the passed query along with the
+// current logins of their users and the current names of their repositories
+// and organizations using a single query.
+//
+// Rollups satisfies the database interface.
+func (c *cockroachdb) Rollups(ctx context.Context, q database.RollupQuery) ([]database.Rollup, error) {
+	log.Debugf("Rollups: %v %v %v %v", q.Organization, q.UserIDs,
+		q.Authors, q.Month)
+
+	tx := c.beginTx(ctx)
+	defer tx.Rollback()
+
+	type rollupRow struct {
+		Rollup
+		UserLogin string
+		RepoName  string
+		OrgLogin  string
+	}
+	db := tx.
+		Table(tableNameRollups).
+		Select(tableNameRollups + ".*, " +
+			"COALESCE(" + tableNameUsers + ".login, " +
+			tableNameRollups + ".author) AS user_login, " +
+			tableNameRepositories + ".name AS repo_name, " +
+			tableNameOrganization + ".login AS org_login").
+		Joins("LEFT JOIN " + tableNameUsers + " ON " +
+			tableNameUsers + ".id = " + tableNameRollups + ".user_id").
+		Joins("JOIN " + tableNameRepositories + " ON " +
+			tableNameRepositories + ".id = " + tableNameRollups + ".repo_id").
+		Joins("JOIN " + tableNameOrganization + " ON " +
+			tableNameOrganization + ".id = " +
+			tableNameRepositories + ".organization_id")
+	if q.Organization != "" {
+		db = db.Where("LOWER("+tableNameOrganization+".login) = LOWER(?)",
+			q.Organization)
+	}
+	if len(q.UserIDs) > 0 || len(q.Authors) > 0 {
+		db = db.Where("("+tableNameRollups+".user_id IN (?) OR ("+
+			tableNameRollups+".user_id = 0 AND "+
+			tableNameRollups+".author IN (?)))", q.UserIDs, q.Authors)
+	}
+	if q.Month != 0 {
+		db = db.Where(tableNameRollups+".month = ?", q.Month)
+	}
+
+	rows := make([]rollupRow, 0, 1024) // PNOOMA
+	err := db.
+		Order("user_login, " + tableNameRollups + ".user_id, " +
+			tableNameRollups + ".author, repo_name").
+		Scan(&rows).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	dbRollups := make([]database.Rollup, 0, len(rows))
+	for _, row := range rows {
+		dbRollup := DecodeRollup(&row.Rollup)
+		dbRollup.Login = row.UserLogin
+		dbRollup.Repo = row.RepoName
+		dbRollup.Organization = row.OrgLogin
+		dbRollups = append(dbRollups, dbRollup)
+	}
+	return dbRollups, nil
+}
@@ -0,0 +1,198 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package cockroachdb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRollupMonth(t *testing.T) {
+	tests := []struct {
+		timestamp int64
+		want      int
+	}{
+		{timestamp: 0, want: 197001},
+		{timestamp: 1588291199, want: 202004}, // 2020-04-30 23:59:59 UTC
+		{timestamp: 1588291200, want: 202005}, // 2020-05-01 00:00:00 UTC
+		{timestamp: 1609459199, want: 202012}, // 2020-12-31 23:59:59 UTC
+		{timestamp: 1609459200, want: 202101}, // 2021-01-01 00:00:00 UTC
+	}
+	for _, test := range tests {
+		got := rollupMonth(test.timestamp)
+		if got != test.want {
+			t.Errorf("rollupMonth(%d): got %d, want %d", test.timestamp,
+				got, test.want)
+		}
+	}
+}
+
+func TestAddRollups(t *testing.T) {
+	const (
+		april = 1586736000 // 2020-04-13 00:00:00 UTC
+		may   = 1589328000 // 2020-05-13 00:00:00 UTC
+	)
+
+	pr := &PullRequest{
+		RepoID:    7,
+		Author:    "alice",
+		AuthorID:  1,
+		Merged:    true,
+		MergedAt:  may,
+		Additions: 10,
+		Deletions: 4,
+		Reviews: []PullRequestReview{
+			{Author: "bob", AuthorID: 2, SubmittedAt: april},
+			{Author: "bob", AuthorID: 2, SubmittedAt: may},
+			{Author: "pending", AuthorID: 3},
+			{SubmittedAt: may},
+		},
+		Commits: []Commit{
+			{
+				Author:     "alice",
+				AuthorID:   1,
+				AuthoredAt: april,
+				Additions:  6,
+				Deletions:  1,
+			},
+			{
+				Author:     "alice",
+				AuthorID:   1,
+				AuthoredAt: april,
+				Additions:  4,
+				Deletions:  3,
+			},
+			{
+				AuthorEmail: "carol@example.com",
+				AuthoredAt:  may,
+				Additions:   2,
+			},
+			{
+				AuthoredAt: may,
+				Additions:  100,
+			},
+		},
+	}
+	want := map[rollupKey]*Rollup{
+		{userID: 1, repoID: 7, month: 202005}: {
+			UserID:          1,
+			RepoID:          7,
+			Month:           202005,
+			PullRequests:    1,
+			MergedAdditions: 10,
+			MergedDeletions: 4,
+		},
+		{userID: 1, repoID: 7, month: 202004}: {
+			UserID:          1,
+			RepoID:          7,
+			Month:           202004,
+			Commits:         2,
+			CommitAdditions: 10,
+			CommitDeletions: 4,
+		},
+		{userID: 2, repoID: 7, month: 202004}: {
+			UserID:            2,
+			RepoID:            7,
+			Month:             202004,
+			Reviews:           1,
+			ReviewedAdditions: 10,
+			ReviewedDeletions: 4,
+		},
+		{userID: 2, repoID: 7, month: 202005}: {
+			UserID:            2,
+			RepoID:            7,
+			Month:             202005,
+			Reviews:           1,
+			ReviewedAdditions: 10,
+			ReviewedDeletions: 4,
+		},
+		{author: "carol@example.com", repoID: 7, month: 202005}: {
+			Author:          "carol@example.com",
+			RepoID:          7,
+			Month:           202005,
+			Commits:         1,
+			CommitAdditions: 2,
+		},
+	}
+
+	tests := []struct {
+		name string
+		pr   *PullRequest
+		sign int64
+		want map[rollupKey]*Rollup
+	}{
+		{
+			name: "add",
+			pr:   pr,
+			sign: 1,
+			want: want,
+		},
+		{
+			name: "remove",
+			pr:   pr,
+			sign: -1,
+			want: negateRollups(want),
+		},
+		{
+			name: "unmerged",
+			pr: &PullRequest{
+				RepoID:   7,
+				AuthorID: 1,
+				MergedAt: may,
+			},
+			sign: 1,
+			want: map[rollupKey]*Rollup{},
+		},
+		{
+			name: "pseudonym",
+			pr: &PullRequest{
+				RepoID:    7,
+				Author:    "erased-1",
+				Merged:    true,
+				MergedAt:  may,
+				Additions: 1,
+			},
+			sign: 1,
+			want: map[rollupKey]*Rollup{
+				{author: "erased-1", repoID: 7, month: 202005}: {
+					Author:          "erased-1",
+					RepoID:          7,
+					Month:           202005,
+					PullRequests:    1,
+					MergedAdditions: 1,
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := make(map[rollupKey]*Rollup)
+			addRollups(got, test.pr, test.sign)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+// negateRollups returns a copy of the passed rollups with all counters
+// negated.
+func negateRollups(rollups map[rollupKey]*Rollup) map[rollupKey]*Rollup {
+	negated := make(map[rollupKey]*Rollup, len(rollups))
+	for key, r := range rollups {
+		n := *r
+		n.PullRequests = -n.PullRequests
+		n.MergedAdditions = -n.MergedAdditions
+		n.MergedDeletions = -n.MergedDeletions
+		n.Reviews = -n.Reviews
+		n.ReviewedAdditions = -n.ReviewedAdditions
+		n.ReviewedDeletions = -n.ReviewedDeletions
+		n.Commits = -n.Commits
+		n.CommitAdditions = -n.CommitAdditions
+		n.CommitDeletions = -n.CommitDeletions
+		negated[key] = &n
+	}
+	return negated
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package cockroachdb
+
+import (
+	"context"
+
+	"github.com/decred/github-tracker/database"
+	"github.com/jinzhu/gorm"
+)
+
+// insertSnapshotQuery inserts a snapshot unless one with the same primary key
+// exists, in which case the stored snapshot is left untouched.
+const insertSnapshotQuery = "INSERT INTO " + tableNameSnapshots +
+	" (organization, login, year, month, payload, hash, created_at)" +
+	" VALUES (?, ?, ?, ?, ?, ?, ?)" +
+	" ON CONFLICT (organization, login, year, month) DO NOTHING"
+
+// NewSnapshots stores the passed snapshots in a single transaction.  Snapshots
+// are immutable, so ErrSnapshotExists is returned and none of the snapshots
+// are stored when a snapshot of the same organization, user and month already
+// exists.  Conflicts are detected by the inserts themselves, so concurrent
+// callers can not both store the snapshots of a month.
+//
+// NewSnapshots satisfies the database interface.
+func (c *cockroachdb) NewSnapshots(ctx context.Context, dbSnapshots []database.Snapshot) error {
+	if len(dbSnapshots) == 0 {
+		return nil
+	}
+	first := dbSnapshots[0]
+
+	log.Debugf("NewSnapshots: %v %v %v", first.Organization, first.Year,
+		first.Month)
+
+	tx := c.beginTx(ctx)
+	for _, dbSnapshot := range dbSnapshots {
+		s := EncodeSnapshot(&dbSnapshot)
+		res := execStatement(tx, tableNameSnapshots, insertSnapshotQuery,
+			s.Organization, s.Login, s.Year, s.Month, s.Payload, s.Hash,
+			s.CreatedAt)
+		if res.Error != nil {
+			tx.Rollback()
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			tx.Rollback()
+			return database.ErrSnapshotExists
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+// SnapshotByUser returns the snapshot of the passed user for the passed
+// organization and month.
+//
+// SnapshotByUser satisfies the database interface.
+func (c *cockroachdb) SnapshotByUser(ctx context.Context, org, user string, year, month int) (*database.Snapshot, error) {
+	log.Debugf("SnapshotByUser: %v %v %v %v", org, user, year, month)
+
+	tx := c.beginTx(ctx)
+	defer tx.Rollback()
+
+	var snapshot Snapshot
+	err := tx.
+		Where("organization = ? AND login = ? AND year = ? AND month = ?",
+			org, user, year, month).
+		First(&snapshot).
+		Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = database.ErrSnapshotNotFound
+		}
+		return nil, err
+	}
+
+	return DecodeSnapshot(&snapshot), nil
+}
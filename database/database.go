@@ -5,6 +5,7 @@
 package database
 
 import (
+	"context"
 	"errors"
 )
 
@@ -22,72 +23,431 @@ var (
 	// ErrShutdown is emitted when the cache is shutting down.
 	ErrShutdown = errors.New("cache is shutting down")
 
+	// ErrInvalidCursor is emitted when a pagination cursor cannot be
+	// decoded.
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	// ErrAliasNotFound indicates that an alias was not found in the
+	// database.
+	ErrAliasNotFound = errors.New("alias not found")
+
+	// ErrAliasExists indicates that an alias is already assigned to a
+	// contributor.
+	ErrAliasExists = errors.New("alias already exists")
+
+	// ErrSnapshotExists indicates that a snapshot of the month already
+	// exists.  Snapshots are immutable and can not be replaced.
+	ErrSnapshotExists = errors.New("snapshot already exists")
+
+	// ErrSnapshotNotFound indicates that no snapshot was found.
+	ErrSnapshotNotFound = errors.New("snapshot not found")
+
 	// ErrUserNotFound indicates that a user name was not found in the
 	// database.
 	ErrUserNotFound = errors.New("user not found")
+
+	// ErrInvalidIdentity indicates that an identity to erase has no
+	// logins or contains empty ones.
+	ErrInvalidIdentity = errors.New("invalid user identity")
+
+	// ErrUserErased indicates that a pull request was authored by a purged
+	// user and is not stored.
+	ErrUserErased = errors.New("user erased")
+
+	// ErrAPIKeyNotFound indicates that an API key was not found in the
+	// database.
+	ErrAPIKeyNotFound = errors.New("api key not found")
 )
 
-// Database interface that is required by the web server.
-type Database interface {
-	NewPullRequest(*PullRequest) error    // Create new pull request
-	UpdatePullRequest(*PullRequest) error // Update exisiting pull request
-	PullRequestByURL(string) (*PullRequest, error)
-	PullRequestsByUserDates(string, int64, int64) ([]*PullRequest, error) // Retreive all pull requests that match username between dates
+// Reader is the read only part of the database.  It is all the JSON-RPC
+// handlers are given access to.
+//
+// Canceling the context passed to a method stops it from running further
+// queries, but a query that is already running is not interrupted and the
+// method only returns once it completes.
+type Reader interface {
+	PullRequestByURL(context.Context, string) (*PullRequest, error)
+	PullRequestByNumber(context.Context, int64, int) (*PullRequest, error)                           // Retrieve a pull request by repository ID and number
+	PullRequestsByUserDates(context.Context, string, []string, int64, int64) ([]*PullRequest, error) // Retreive the pull requests of an organization merged by any of the usernames in [start, end)
+	PullRequests(context.Context, PullRequestQuery) ([]*PullRequest, string, error)                  // Retrieve a page of pull requests that match the query along with the next cursor
+	PullRequestHistory(context.Context, string) ([]Change, error)                                    // Retrieve the changes applied to a pull request, its commits and reviews
+	ForEachPullRequest(context.Context, func(*PullRequest) error) error                              // Call the function for every pull request along with its commits and reviews
+
+	Organizations(context.Context) ([]Organization, error)     // Retrieve all organizations
+	Repositories(context.Context) ([]Repository, error)        // Retrieve all repositories
+	RepositoryNames(context.Context) ([]RepositoryName, error) // Retrieve the name history of all repositories
+
+	AllUsersByDates(context.Context, string, int64, int64) ([]string, error) // Retrieve the authors of pull requests of an organization merged or reviews submitted in [start, end)
+	UserByLogin(context.Context, string) (*User, error)                      // Retrieve the user that uses or used the login
+	Users(context.Context) ([]User, error)                                   // Retrieve all users
+	UserLogins(context.Context) ([]UserLogin, error)                         // Retrieve the login history of all users
+
+	CommitsByUserDates(context.Context, string, []string, []string, int64, int64) ([]Commit, error) // Retrieve the commits on pull requests of an organization authored by any of the usernames or emails in [start, end)
+
+	AliasesByContributor(context.Context, string) ([]Alias, error)      // Retrieve the aliases of a contributor
+	Aliases(context.Context) ([]Alias, error)                           // Retrieve all aliases
+	ContributorByAlias(context.Context, string, string) (string, error) // Retrieve the contributor an alias is assigned to
+
+	Rollups(context.Context, RollupQuery) ([]Rollup, error) // Retrieve the monthly per user and repository rollups that match the query
+
+	SnapshotByUser(context.Context, string, string, int, int) (*Snapshot, error) // Retrieve the snapshot of a user by organization, user, year and month
+
+	ReviewsByUserDates(context.Context, string, []string, int64, int64) ([]PullRequestReview, error) // Retreive the reviews on pull requests of an organization by any of the usernames in [start, end)
+
+	APIKeyByHash(context.Context, string) (*APIKey, error) // Retrieve the API key with the hash
+	APIKeys(context.Context) ([]APIKey, error)             // Retrieve all API keys
+
+	ErasedIdentities(context.Context) ([]ErasedIdentity, error) // Retrieve the identities of all purged and pseudonymized users
+}
 
-	AllUsersByDates(int64, int64) ([]string, error)
+// Writer is the part of the database that modifies stored records.
+type Writer interface {
+	NewPullRequest(context.Context, *PullRequest) error    // Create new pull request
+	UpdatePullRequest(context.Context, *PullRequest) error // Update exisiting pull request
+	UpsertPullRequest(context.Context, *PullRequest) error // Create or replace pull request along with its commits and reviews, scrubbing erased users
 
-	NewCommit(*Commit) error    // Create new commit
-	UpdateCommit(*Commit) error // Update existing commit
+	UpsertRepository(context.Context, *Repository) error          // Create or update a repository and its organization, following renames
+	RestoreRepositoryName(context.Context, *RepositoryName) error // Store a name of a repository along with when it was seen
+	RepositorySynced(context.Context, int64, int64) error         // Record the time a repository was last synced
+	UpsertOrganization(context.Context, *Organization) error      // Create or update an organization, keeping its last sync time
+	OrganizationSynced(context.Context, int64, int64) error       // Record the time an organization was last synced
 
-	NewPullRequestReview(*PullRequestReview) error                        // Create new pull request review
-	UpdatePullRequestReview(*PullRequestReview) error                     // Update existing pull request review
-	ReviewsByUserDates(string, int64, int64) ([]PullRequestReview, error) // Retreive all reviews that match username between dates
+	UpsertUser(context.Context, *User) error                      // Create or update a user along with all of its logins
+	RestoreUserLogin(context.Context, *UserLogin) error           // Store a login of a user along with when it was seen
+	PurgeUser(context.Context, UserIdentity) error                // Remove all data tied to the identity and record its erasure
+	PseudonymizeUser(context.Context, UserIdentity, string) error // Replace the identity with the pseudonym in all data and record its erasure
+	RestoreErasedIdentity(context.Context, *ErasedIdentity) error // Record an erased identity
 
-	Setup() error
+	NewCommit(context.Context, *Commit) error                  // Create new commit
+	UpdateCommit(context.Context, *Commit) error               // Update existing commit
+	PurgeCommitMessages(context.Context, int64) (int64, error) // Drop the message bodies of commits authored before the date
+
+	NewAlias(context.Context, *Alias) error            // Assign a login or email to a contributor
+	DeleteAlias(context.Context, string, string) error // Remove an alias by type and value
+
+	NewSnapshots(context.Context, []Snapshot) error // Store the snapshots of a month, failing when the month was already snapshotted
+
+	NewAPIKey(context.Context, *APIKey) error        // Create new API key
+	DeleteAPIKey(context.Context, string) error      // Remove an API key by ID
+	APIKeyUsed(context.Context, string, int64) error // Record the time an API key was last used
+
+	NewPullRequestReview(context.Context, *PullRequestReview) error    // Create new pull request review
+	UpdatePullRequestReview(context.Context, *PullRequestReview) error // Update existing pull request review
+}
+
+// Admin is the part of the database that manages its lifecycle.
+type Admin interface {
+	// Setup creates the tables that do not exist yet.
+	Setup(context.Context) error
+
+	// Build drops all tables holding data fetched from GitHub and
+	// recreates them.  Data that can not be refetched is kept.
+	Build(context.Context) error
+
+	// Ping checks that the backend is reachable.
+	Ping(context.Context) error
 
 	// Close performs cleanup of the backend.
 	Close() error
 }
 
+// ReadWriter is the part of the database that reads and modifies stored
+// records.
+type ReadWriter interface {
+	Reader
+	Writer
+}
+
+// Database interface that is required by the web server.
+type Database interface {
+	Reader
+	Writer
+	Admin
+}
+
+// UserIdentity describes all logins and commit emails of a person along with
+// the contributor their aliases are assigned to, if any.
+type UserIdentity struct {
+	Contributor string
+	Logins      []string
+	Emails      []string
+	UserIDs     []int64 // Known GitHub accounts, others are found by login
+}
+
+// Organization is a GitHub organization or user account that owns
+// repositories.
+type Organization struct {
+	ID       int64
+	Login    string
+	LastSync int64 // UNIX timestamp of the last completed sync
+}
+
+// Repository is a GitHub repository identified by its numeric ID, which unlike
+// its name does not change when the repository is renamed or transferred.
+type Repository struct {
+	ID             int64
+	OrganizationID int64
+	Organization   string // Login of the owning organization
+	Name           string
+	FullName       string
+	DefaultBranch  string
+	Fork           bool
+	Archived       bool
+	Private        bool
+	LastSync       int64    // UNIX timestamp of the last completed sync, only populated when reading
+	Names          []string // All full names the repository is known by, only populated when reading
+}
+
+// RepositoryName records a full name that was used by a repository along with
+// the UNIX timestamps it was first and last seen with.
+type RepositoryName struct {
+	RepositoryID int64
+	FullName     string
+	FirstSeen    int64
+	LastSeen     int64
+}
+
+// PullRequest is uniquely identified by the ID of its repository and its
+// number.
 type PullRequest struct {
-	Repo         string
-	Organization string
-	User         string
-	URL          string
-	Number       int
-	UpdatedAt    int64
-	ClosedAt     int64
-	MergedAt     int64
-	Merged       bool
-	State        string
-	Additions    int
-	Deletions    int
-	MergedBy     string
+	RepoID             int64
+	Repo               string
+	Organization       string
+	User               string
+	UserID             int64
+	URL                string
+	Number             int
+	Title              string
+	Body               string
+	Labels             []string
+	BaseRef            string // Branch the PR is merged into
+	HeadRef            string // Branch the PR is merged from
+	HeadRepo           string // Full name of the head repository
+	Fork               bool   // Whether the head repository is a fork
+	Draft              bool
+	CreatedAt          int64
+	UpdatedAt          int64
+	ClosedAt           int64
+	MergedAt           int64
+	Merged             bool
+	State              string
+	Additions          int
+	Deletions          int
+	MergedBy           string
+	RequestedReviewers []string
+	Milestone          string
 
 	Commits []Commit
 	Reviews []PullRequestReview
+
+	// Users contains the GitHub accounts referenced by the pull request,
+	// its commits and its reviews.  They are recorded along with the pull
+	// request and are not populated when reading.
+	Users []User
+}
+
+// User is a GitHub account identified by its numeric ID, which unlike the
+// login does not change when the account is renamed.
+type User struct {
+	ID     int64
+	NodeID string
+	Login  string   // Current login
+	Type   string   // User, Bot or Organization
+	Logins []string // All logins the user is known by, including the current one
+}
+
+// UserLogin records a login that was used by a GitHub account along with the
+// UNIX timestamps it was first and last seen with.
+type UserLogin struct {
+	UserID    int64
+	Login     string
+	FirstSeen int64
+	LastSeen  int64
+}
+
+// PullRequestSortField identifies the field that pull request queries are
+// sorted by.
+type PullRequestSortField int
+
+const (
+	SortByUpdated PullRequestSortField = iota
+	SortByMerged
+	SortByClosed
+	SortByNumber
+	SortByCreated
+)
+
+// PullRequestQuery describes the filters, order and page of a pull request
+// query.  Empty fields are not used to filter results, the organization,
+// repository and author are matched case-insensitively and time ranges are
+// inclusive UNIX timestamps.
+type PullRequestQuery struct {
+	Organization string
+	Repo         string
+	Author       string
+	State        string
+	Label        string
+
+	MergedAfter   int64
+	MergedBefore  int64
+	ClosedAfter   int64
+	ClosedBefore  int64
+	UpdatedAfter  int64
+	UpdatedBefore int64
+
+	SortBy    PullRequestSortField
+	Ascending bool
+
+	// Cursor is the opaque cursor returned by a previous query.  When set,
+	// results continue after the last pull request of the previous page.
+	Cursor string
+	Limit  int
+}
+
+// Change record types.
+const (
+	ChangeRecordPullRequest = "pullrequest"
+	ChangeRecordReview      = "review"
+	ChangeRecordCommit      = "commit"
+)
+
+// Change actions.
+const (
+	ChangeCreate = "create"
+	ChangeUpdate = "update"
+	ChangeDelete = "delete"
+)
+
+// Change is an entry of the append-only history of a pull request.  It records
+// a single field change that sync applied to the pull request or to one of its
+// reviews or commits, or the addition or removal of a review or commit.
+// Changes are only recorded for pull requests that were already stored.
+type Change struct {
+	ID             int64
+	RepoID         int64
+	Number         int
+	PullRequestURL string // URL of the pull request at the time of the change
+	Record         string // ChangeRecordPullRequest, ChangeRecordReview or ChangeRecordCommit
+	RecordID       string // Review ID or commit SHA, empty for pull requests
+	Action         string // ChangeCreate, ChangeUpdate or ChangeDelete
+	Field          string // Changed field, only set for updates
+	Old            string
+	New            string
+	ChangedAt      int64
+}
+
+// Rollup holds the monthly totals of a user in a repository.  Rollups are
+// maintained by sync as pull requests are stored and are bucketed by UTC
+// calendar month.  Merged totals count pull requests by their merge time,
+// reviewed totals count reviews by their submission time along with the size
+// of the reviewed pull request, and commit totals count commits by their
+// authored time.  Rollups use the same UTC calendar months as the user
+// information.  Contributions are rolled up under the ID of the GitHub account
+// of the user and otherwise under their login or commit author email.
+type Rollup struct {
+	Login             string // Current login of the user, or Author
+	UserID            int64  // Zero for contributions without an account
+	Author            string // Login or email of contributions without an account
+	RepoID            int64
+	Repo              string // Current name of the repository
+	Organization      string // Current login of the owning organization
+	Month             int    // Year and month as YYYYMM
+	PullRequests      int64
+	MergedAdditions   int64
+	MergedDeletions   int64
+	Reviews           int64
+	ReviewedAdditions int64
+	ReviewedDeletions int64
+	Commits           int64
+	CommitAdditions   int64
+	CommitDeletions   int64
+}
+
+// RollupQuery describes the rollups to retrieve.  Empty fields are not used to
+// filter results.
+type RollupQuery struct {
+	Organization string
+	UserIDs      []int64  // Accounts of the user
+	Authors      []string // Logins and emails of contributions without an account
+	Month        int      // Year and month as YYYYMM
+}
+
+// Snapshot is the frozen user information of a user for a month.  Payload is
+// the serialized user information and Hash is the hex encoded SHA-256 of the
+// payload.
+type Snapshot struct {
+	Organization string
+	User         string // Contributor, or login of users without aliases
+	Year         int
+	Month        int
+	Payload      []byte
+	Hash         string
+	CreatedAt    int64
+}
+
+// Alias types.
+const (
+	AliasLogin = "login"
+	AliasEmail = "email"
+)
+
+// Alias assigns a GitHub login or a commit author email to a canonical
+// contributor.  A contributor may have several aliases, which allows stats to
+// be aggregated across multiple accounts and commits authored with emails that
+// are not linked to any account.
+type Alias struct {
+	Contributor string
+	Type        string // AliasLogin or AliasEmail
+	Value       string
+}
+
+// API key scopes.
+const (
+	APIKeyScopeRead  = "read"
+	APIKeyScopeSync  = "sync"
+	APIKeyScopeAdmin = "admin"
+)
+
+// APIKey is a key that authenticates RPC callers with the permissions of its
+// scope.  Only the hash of the key is stored.
+type APIKey struct {
+	ID         string
+	Name       string
+	Hash       string // Hex encoded SHA-256 hash of the key
+	Scope      string // APIKeyScopeRead, APIKeyScopeSync or APIKeyScopeAdmin
+	CreatedAt  int64
+	ExpiresAt  int64 // Zero when the key does not expire
+	LastUsedAt int64 // Zero when the key was never used
 }
 
 type Commit struct {
-	SHA       string
-	URL       string
-	Message   string
-	Author    string
-	Committer string
-	Additions int
-	Deletions int
+	PullRequestURL string
+	Repo           string // Only populated when reading
+	SHA            string
+	URL            string
+	Message        string
+	Author         string
+	AuthorID       int64
+	AuthorEmail    string
+	AuthoredAt     int64
+	Committer      string
+	CommitterID    int64
+	Additions      int
+	Deletions      int
 }
 
 type PullRequestReview struct {
-	ID          int64
-	Author      string
-	State       string
-	SubmittedAt int64
-	CommitID    string
-	Repo        string
-	Number      int
-	Additions   int
-	Deletions   int
+	ID             int64
+	PullRequestURL string
+	Author         string
+	AuthorID       int64
+	State          string
+	SubmittedAt    int64
+	CommitID       string
+	Repo           string
+	Number         int
+	Additions      int
+	Deletions      int
 }
 
 /*
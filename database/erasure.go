@@ -0,0 +1,206 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Erased identity types.
+const (
+	ErasedUserID = "userid" // Decimal GitHub account ID
+	ErasedLogin  = "login"  // Lowercase GitHub login
+	ErasedEmail  = "email"  // Lowercase commit author email
+)
+
+// ErasedIdentity records an account ID, login or commit email of a user that
+// was purged or pseudonymized, so that the user is not stored again when its
+// data is refetched, imported or reindexed.
+type ErasedIdentity struct {
+	Type      string // ErasedUserID, ErasedLogin or ErasedEmail
+	Value     string
+	Pseudonym string // Replaces the user, empty when the user was purged
+	ErasedAt  int64  // UNIX timestamp of the erasure
+}
+
+// NewErasedIdentities returns the erased identities that record the erasure of
+// the passed identity along with the passed GitHub account IDs.
+func NewErasedIdentities(identity *UserIdentity, userIDs []int64, pseudonym string, erasedAt int64) []ErasedIdentity {
+	erased := make([]ErasedIdentity, 0,
+		len(userIDs)+len(identity.Logins)+len(identity.Emails))
+	add := func(erasedType, value string) {
+		erased = append(erased, ErasedIdentity{
+			Type:      erasedType,
+			Value:     value,
+			Pseudonym: pseudonym,
+			ErasedAt:  erasedAt,
+		})
+	}
+	for _, id := range userIDs {
+		add(ErasedUserID, strconv.FormatInt(id, 10))
+	}
+	for _, login := range identity.Logins {
+		add(ErasedLogin, strings.ToLower(login))
+	}
+	for _, email := range identity.Emails {
+		add(ErasedEmail, strings.ToLower(email))
+	}
+	return erased
+}
+
+// Erasures matches users against erased identities.  A nil Erasures matches
+// nothing.
+type Erasures struct {
+	userIDs map[int64]string // Pseudonyms by account ID
+	logins  map[string]string
+	emails  map[string]string
+}
+
+// NewErasures returns the erasures of the passed erased identities.
+func NewErasures(erased []ErasedIdentity) *Erasures {
+	e := &Erasures{
+		userIDs: make(map[int64]string),
+		logins:  make(map[string]string),
+		emails:  make(map[string]string),
+	}
+	for i := range erased {
+		e.Add(&erased[i])
+	}
+	return e
+}
+
+// Add adds the passed erased identity.  Identities of unknown types are
+// ignored.
+func (e *Erasures) Add(erased *ErasedIdentity) {
+	switch erased.Type {
+	case ErasedUserID:
+		id, err := strconv.ParseInt(erased.Value, 10, 64)
+		if err == nil && id != 0 {
+			e.userIDs[id] = erased.Pseudonym
+		}
+	case ErasedLogin:
+		e.logins[strings.ToLower(erased.Value)] = erased.Pseudonym
+	case ErasedEmail:
+		e.emails[strings.ToLower(erased.Value)] = erased.Pseudonym
+	}
+}
+
+// Match returns whether the passed account ID, login or email belongs to an
+// erased user along with the pseudonym that replaces the user, which is empty
+// when the user was purged.  Zero IDs and empty logins and emails never match.
+func (e *Erasures) Match(userID int64, login, email string) (string, bool) {
+	if e == nil {
+		return "", false
+	}
+	if pseudonym, ok := e.userIDs[userID]; ok && userID != 0 {
+		return pseudonym, true
+	}
+	if pseudonym, ok := e.logins[strings.ToLower(login)]; ok && login != "" {
+		return pseudonym, true
+	}
+	if pseudonym, ok := e.emails[strings.ToLower(email)]; ok && email != "" {
+		return pseudonym, true
+	}
+	return "", false
+}
+
+// Purged returns whether the passed account ID or login belongs to a purged
+// user.
+func (e *Erasures) Purged(userID int64, login string) bool {
+	pseudonym, ok := e.Match(userID, login, "")
+	return ok && pseudonym == ""
+}
+
+// MatchUser returns whether the passed account, or any login it is known by,
+// belongs to an erased user.
+func (e *Erasures) MatchUser(user *User) bool {
+	if _, ok := e.Match(user.ID, user.Login, ""); ok {
+		return true
+	}
+	for _, login := range user.Logins {
+		if _, ok := e.Match(0, login, ""); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ScrubPullRequest removes the erased users from the passed pull request in
+// place.  The reviews, commits and requested reviews of purged users are
+// removed along with the accounts of all erased users, and pseudonymized users
+// are replaced by their pseudonym.  ErrUserErased is returned when the pull
+// request was authored by a purged user and must not be stored at all.
+func (e *Erasures) ScrubPullRequest(pr *PullRequest) error {
+	if e == nil {
+		return nil
+	}
+
+	if pseudonym, ok := e.Match(pr.UserID, pr.User, ""); ok {
+		if pseudonym == "" {
+			return ErrUserErased
+		}
+		pr.User = pseudonym
+		pr.UserID = 0
+	}
+	if pseudonym, ok := e.Match(0, pr.MergedBy, ""); ok {
+		pr.MergedBy = pseudonym
+	}
+
+	reviews := pr.Reviews[:0]
+	for _, review := range pr.Reviews {
+		pseudonym, ok := e.Match(review.AuthorID, review.Author, "")
+		switch {
+		case ok && pseudonym == "":
+			continue
+		case ok:
+			review.Author = pseudonym
+			review.AuthorID = 0
+		}
+		reviews = append(reviews, review)
+	}
+	pr.Reviews = reviews
+
+	commits := pr.Commits[:0]
+	for _, commit := range pr.Commits {
+		pseudonym, ok := e.Match(commit.AuthorID, commit.Author,
+			commit.AuthorEmail)
+		switch {
+		case ok && pseudonym == "":
+			continue
+		case ok:
+			commit.Author = pseudonym
+			commit.AuthorID = 0
+			commit.AuthorEmail = ""
+		}
+		if pseudonym, ok := e.Match(commit.CommitterID, commit.Committer,
+			""); ok {
+			commit.Committer = pseudonym
+			commit.CommitterID = 0
+		}
+		commits = append(commits, commit)
+	}
+	pr.Commits = commits
+
+	requested := pr.RequestedReviewers[:0]
+	for _, login := range pr.RequestedReviewers {
+		if _, ok := e.Match(0, login, ""); ok {
+			continue
+		}
+		requested = append(requested, login)
+	}
+	pr.RequestedReviewers = requested
+
+	users := pr.Users[:0]
+	for _, user := range pr.Users {
+		if e.MatchUser(&user) {
+			continue
+		}
+		users = append(users, user)
+	}
+	pr.Users = users
+
+	return nil
+}
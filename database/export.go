@@ -0,0 +1,328 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ExportVersion is the version of the export format written by Export.
+// Version 2 added the login and repository name history and the erased
+// identities.  Older versions are still imported.
+const ExportVersion = 2
+
+// Export record types.
+const (
+	ExportHeader         = "header"
+	ExportErasedIdentity = "erasedidentity"
+	ExportRepository     = "repository"
+	ExportRepositoryName = "repositoryname"
+	ExportOrganization   = "organization"
+	ExportUser           = "user"
+	ExportUserLogin      = "userlogin"
+	ExportAlias          = "alias"
+	ExportPullRequest    = "pullrequest"
+)
+
+// ErrUnsupportedExport is returned when importing records of an unknown
+// version or type.
+var ErrUnsupportedExport = errors.New("unsupported export record")
+
+// exportRecord is a single line of an export.
+type exportRecord struct {
+	Version int             `json:"version"`
+	Type    string          `json:"type"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// exportHeaderData is the data of the header record that starts an export.
+type exportHeaderData struct {
+	Created int64 `json:"created"`
+}
+
+// Export writes every repository, organization, user, alias and pull request,
+// along with its commits and reviews, stored in the passed database to the
+// passed writer as JSON lines.  The name history of repositories, the login
+// history of users and the identities of erased users are written as well.
+// Every line is a record that carries the export version and the record type.
+// Erased identities are written first, repositories before their names,
+// organizations and pull requests, and users before their logins, so that an
+// import can restore them in order.  Data derived from pull requests, such as
+// rollups, is not exported since it is rebuilt on import, and neither are the
+// change history and snapshots.
+func Export(ctx context.Context, db Reader, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	write := func(recordType string, data interface{}) error {
+		b, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		return enc.Encode(exportRecord{
+			Version: ExportVersion,
+			Type:    recordType,
+			Data:    b,
+		})
+	}
+
+	err := write(ExportHeader, exportHeaderData{
+		Created: time.Now().Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	erased, err := db.ErasedIdentities(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range erased {
+		err := write(ExportErasedIdentity, &erased[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	repos, err := db.Repositories(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range repos {
+		err := write(ExportRepository, &repos[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	names, err := db.RepositoryNames(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range names {
+		err := write(ExportRepositoryName, &names[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	orgs, err := db.Organizations(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range orgs {
+		err := write(ExportOrganization, &orgs[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	users, err := db.Users(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range users {
+		err := write(ExportUser, &users[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	logins, err := db.UserLogins(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range logins {
+		err := write(ExportUserLogin, &logins[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	aliases, err := db.Aliases(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range aliases {
+		err := write(ExportAlias, &aliases[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return db.ForEachPullRequest(ctx, func(pr *PullRequest) error {
+		return write(ExportPullRequest, pr)
+	})
+}
+
+// Import reads the records written by Export from the passed reader and stores
+// them in the passed database.  Existing records are updated, so an export
+// may be imported repeatedly.  An alias that is already assigned to another
+// contributor fails the import.  Users erased in either the export or the
+// passed database are not imported: their accounts, logins, aliases and
+// authored pull requests are skipped and they are scrubbed from the remaining
+// pull requests.
+func Import(ctx context.Context, db ReadWriter, r io.Reader) error {
+	erased, err := db.ErasedIdentities(ctx)
+	if err != nil {
+		return err
+	}
+	erasures := NewErasures(erased)
+
+	dec := json.NewDecoder(r)
+	for line := 1; ; line++ {
+		var record exportRecord
+		err := dec.Decode(&record)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("record %d: %v", line, err)
+		}
+		if record.Version < 1 || record.Version > ExportVersion {
+			return fmt.Errorf("record %d: %w: version %d", line,
+				ErrUnsupportedExport, record.Version)
+		}
+
+		err = importRecord(ctx, db, erasures, &record)
+		if err != nil {
+			return fmt.Errorf("record %d: %w", line, err)
+		}
+	}
+}
+
+// importRecord stores a single export record in the passed database unless it
+// belongs to an erased user.  Imported erased identities are added to the
+// passed erasures.
+func importRecord(ctx context.Context, db ReadWriter, erasures *Erasures, record *exportRecord) error {
+	switch record.Type {
+	case ExportHeader:
+		var header exportHeaderData
+		return json.Unmarshal(record.Data, &header)
+
+	case ExportErasedIdentity:
+		var erased ErasedIdentity
+		err := json.Unmarshal(record.Data, &erased)
+		if err != nil {
+			return err
+		}
+		erasures.Add(&erased)
+		return db.RestoreErasedIdentity(ctx, &erased)
+
+	case ExportRepository:
+		var repo Repository
+		err := json.Unmarshal(record.Data, &repo)
+		if err != nil {
+			return err
+		}
+		err = db.UpsertRepository(ctx, &repo)
+		if err != nil {
+			return err
+		}
+		if repo.LastSync == 0 {
+			return nil
+		}
+		return db.RepositorySynced(ctx, repo.ID, repo.LastSync)
+
+	case ExportRepositoryName:
+		var name RepositoryName
+		err := json.Unmarshal(record.Data, &name)
+		if err != nil {
+			return err
+		}
+		return db.RestoreRepositoryName(ctx, &name)
+
+	case ExportOrganization:
+		var org Organization
+		err := json.Unmarshal(record.Data, &org)
+		if err != nil {
+			return err
+		}
+		err = db.UpsertOrganization(ctx, &org)
+		if err != nil {
+			return err
+		}
+		if org.LastSync == 0 {
+			return nil
+		}
+		return db.OrganizationSynced(ctx, org.ID, org.LastSync)
+
+	case ExportUser:
+		var user User
+		err := json.Unmarshal(record.Data, &user)
+		if err != nil {
+			return err
+		}
+		if erasures.MatchUser(&user) {
+			return nil
+		}
+		return db.UpsertUser(ctx, &user)
+
+	case ExportUserLogin:
+		var login UserLogin
+		err := json.Unmarshal(record.Data, &login)
+		if err != nil {
+			return err
+		}
+		if _, ok := erasures.Match(login.UserID, login.Login, ""); ok {
+			return nil
+		}
+		return db.RestoreUserLogin(ctx, &login)
+
+	case ExportAlias:
+		var alias Alias
+		err := json.Unmarshal(record.Data, &alias)
+		if err != nil {
+			return err
+		}
+		var login, email string
+		switch alias.Type {
+		case AliasLogin:
+			login = alias.Value
+		case AliasEmail:
+			email = alias.Value
+		}
+		if _, ok := erasures.Match(0, login, email); ok {
+			return nil
+		}
+		err = db.NewAlias(ctx, &alias)
+		if !errors.Is(err, ErrAliasExists) {
+			return err
+		}
+		contributor, err := db.ContributorByAlias(ctx, alias.Type,
+			alias.Value)
+		if err != nil {
+			return err
+		}
+		if contributor != alias.Contributor {
+			return fmt.Errorf("%w: %v %v is assigned to %v, not %v",
+				ErrAliasExists, alias.Type, alias.Value, contributor,
+				alias.Contributor)
+		}
+		return nil
+
+	case ExportPullRequest:
+		var pr PullRequest
+		err := json.Unmarshal(record.Data, &pr)
+		if err != nil {
+			return err
+		}
+		err = erasures.ScrubPullRequest(&pr)
+		if errors.Is(err, ErrUserErased) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		err = db.UpsertPullRequest(ctx, &pr)
+		if errors.Is(err, ErrUserErased) {
+			return nil
+		}
+		return err
+	}
+
+	return fmt.Errorf("%w: type %q", ErrUnsupportedExport, record.Type)
+}
@@ -0,0 +1,435 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// memDB stores the records that are exported and imported in memory.  All
+// other database methods panic.
+type memDB struct {
+	ReadWriter
+
+	erased  []ErasedIdentity
+	repos   []Repository
+	names   []RepositoryName
+	orgs    []Organization
+	users   []User
+	logins  []UserLogin
+	aliases []Alias
+	prs     []*PullRequest
+}
+
+func (db *memDB) ErasedIdentities(ctx context.Context) ([]ErasedIdentity, error) {
+	return db.erased, nil
+}
+
+func (db *memDB) RestoreErasedIdentity(ctx context.Context, erased *ErasedIdentity) error {
+	db.erased = append(db.erased, *erased)
+	return nil
+}
+
+func (db *memDB) Repositories(ctx context.Context) ([]Repository, error) {
+	return db.repos, nil
+}
+
+func (db *memDB) UpsertRepository(ctx context.Context, repo *Repository) error {
+	for i := range db.repos {
+		if db.repos[i].ID == repo.ID {
+			lastSync := db.repos[i].LastSync
+			db.repos[i] = *repo
+			db.repos[i].LastSync = lastSync
+			return nil
+		}
+	}
+	r := *repo
+	r.LastSync = 0
+	db.repos = append(db.repos, r)
+	return nil
+}
+
+func (db *memDB) RepositorySynced(ctx context.Context, id, lastSync int64) error {
+	for i := range db.repos {
+		if db.repos[i].ID == id {
+			db.repos[i].LastSync = lastSync
+		}
+	}
+	return nil
+}
+
+func (db *memDB) RepositoryNames(ctx context.Context) ([]RepositoryName, error) {
+	return db.names, nil
+}
+
+func (db *memDB) RestoreRepositoryName(ctx context.Context, name *RepositoryName) error {
+	db.names = append(db.names, *name)
+	return nil
+}
+
+func (db *memDB) Organizations(ctx context.Context) ([]Organization, error) {
+	return db.orgs, nil
+}
+
+func (db *memDB) UpsertOrganization(ctx context.Context, org *Organization) error {
+	for i := range db.orgs {
+		if db.orgs[i].ID == org.ID {
+			db.orgs[i].Login = org.Login
+			return nil
+		}
+	}
+	db.orgs = append(db.orgs, Organization{ID: org.ID, Login: org.Login})
+	return nil
+}
+
+func (db *memDB) OrganizationSynced(ctx context.Context, id, lastSync int64) error {
+	for i := range db.orgs {
+		if db.orgs[i].ID == id {
+			db.orgs[i].LastSync = lastSync
+		}
+	}
+	return nil
+}
+
+func (db *memDB) Users(ctx context.Context) ([]User, error) {
+	return db.users, nil
+}
+
+func (db *memDB) UpsertUser(ctx context.Context, user *User) error {
+	for i := range db.users {
+		if db.users[i].ID == user.ID {
+			db.users[i] = *user
+			return nil
+		}
+	}
+	db.users = append(db.users, *user)
+	return nil
+}
+
+func (db *memDB) UserLogins(ctx context.Context) ([]UserLogin, error) {
+	return db.logins, nil
+}
+
+func (db *memDB) RestoreUserLogin(ctx context.Context, login *UserLogin) error {
+	db.logins = append(db.logins, *login)
+	return nil
+}
+
+func (db *memDB) Aliases(ctx context.Context) ([]Alias, error) {
+	return db.aliases, nil
+}
+
+func (db *memDB) NewAlias(ctx context.Context, alias *Alias) error {
+	for _, a := range db.aliases {
+		if a.Type == alias.Type && a.Value == alias.Value {
+			return ErrAliasExists
+		}
+	}
+	db.aliases = append(db.aliases, *alias)
+	return nil
+}
+
+func (db *memDB) ContributorByAlias(ctx context.Context, aliasType, value string) (string, error) {
+	for _, a := range db.aliases {
+		if a.Type == aliasType && a.Value == value {
+			return a.Contributor, nil
+		}
+	}
+	return "", ErrAliasNotFound
+}
+
+func (db *memDB) ForEachPullRequest(ctx context.Context, f func(*PullRequest) error) error {
+	for _, pr := range db.prs {
+		err := f(pr)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *memDB) UpsertPullRequest(ctx context.Context, pr *PullRequest) error {
+	for i := range db.prs {
+		if db.prs[i].URL == pr.URL {
+			db.prs[i] = pr
+			return nil
+		}
+	}
+	db.prs = append(db.prs, pr)
+	return nil
+}
+
+// testDB returns a database holding a record of every exported type.
+func testDB() *memDB {
+	return &memDB{
+		erased: []ErasedIdentity{{
+			Type:      ErasedLogin,
+			Value:     "mallory",
+			Pseudonym: "erased-1",
+			ErasedAt:  1590000000,
+		}},
+		repos: []Repository{{
+			ID:             10,
+			OrganizationID: 1,
+			Organization:   "decred",
+			Name:           "dcrd",
+			FullName:       "decred/dcrd",
+			DefaultBranch:  "master",
+			LastSync:       1590000000,
+			Names:          []string{"decred/dcrd", "decred/btcd"},
+		}},
+		names: []RepositoryName{{
+			RepositoryID: 10,
+			FullName:     "decred/btcd",
+			FirstSeen:    1500000000,
+			LastSeen:     1550000000,
+		}, {
+			RepositoryID: 10,
+			FullName:     "decred/dcrd",
+			FirstSeen:    1550000000,
+			LastSeen:     1590000000,
+		}},
+		orgs: []Organization{{
+			ID:       1,
+			Login:    "decred",
+			LastSync: 1590000000,
+		}},
+		users: []User{{
+			ID:     100,
+			NodeID: "MDQ6VXNlcjEwMA==",
+			Login:  "alice",
+			Type:   "User",
+			Logins: []string{"alice", "alice-old"},
+		}},
+		logins: []UserLogin{{
+			UserID:    100,
+			Login:     "alice-old",
+			FirstSeen: 1500000000,
+			LastSeen:  1550000000,
+		}},
+		aliases: []Alias{{
+			Contributor: "Alice",
+			Type:        AliasEmail,
+			Value:       "alice@example.com",
+		}},
+		prs: []*PullRequest{{
+			RepoID:             10,
+			Repo:               "dcrd",
+			Organization:       "decred",
+			User:               "alice",
+			UserID:             100,
+			URL:                "https://github.com/decred/dcrd/pull/1",
+			Number:             1,
+			Title:              "multi: Fix",
+			Labels:             []string{"bug"},
+			MergedAt:           1580000000,
+			Merged:             true,
+			State:              "closed",
+			Additions:          10,
+			RequestedReviewers: []string{"bob"},
+			Commits: []Commit{{
+				PullRequestURL: "https://github.com/decred/dcrd/pull/1",
+				SHA:            "abc",
+				Author:         "alice",
+				AuthorID:       100,
+				AuthorEmail:    "alice@example.com",
+				AuthoredAt:     1570000000,
+			}},
+			Reviews: []PullRequestReview{{
+				ID:             1000,
+				PullRequestURL: "https://github.com/decred/dcrd/pull/1",
+				Author:         "bob",
+				AuthorID:       101,
+				State:          "APPROVED",
+				SubmittedAt:    1575000000,
+			}},
+		}},
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	src := testDB()
+
+	var buf bytes.Buffer
+	err := Export(ctx, src, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Import twice to check that imports are repeatable.
+	dst := new(memDB)
+	for i := 0; i < 2; i++ {
+		err = Import(ctx, dst, bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("import %d: %v", i, err)
+		}
+	}
+
+	if !reflect.DeepEqual(dst.repos, src.repos) {
+		t.Errorf("repositories: got %+v, want %+v", dst.repos, src.repos)
+	}
+	if !reflect.DeepEqual(dst.orgs, src.orgs) {
+		t.Errorf("organizations: got %+v, want %+v", dst.orgs, src.orgs)
+	}
+	if !reflect.DeepEqual(dst.users, src.users) {
+		t.Errorf("users: got %+v, want %+v", dst.users, src.users)
+	}
+	if !reflect.DeepEqual(dst.aliases, src.aliases) {
+		t.Errorf("aliases: got %+v, want %+v", dst.aliases, src.aliases)
+	}
+	if !reflect.DeepEqual(dst.prs, src.prs) {
+		t.Errorf("pull requests: got %+v, want %+v", dst.prs, src.prs)
+	}
+
+	// Restored histories and erasures are stored once per import.
+	double := func(v interface{}) interface{} {
+		rv := reflect.ValueOf(v)
+		return reflect.AppendSlice(rv, rv).Interface()
+	}
+	if !reflect.DeepEqual(dst.names, double(src.names)) {
+		t.Errorf("repository names: got %+v, want %+v", dst.names,
+			src.names)
+	}
+	if !reflect.DeepEqual(dst.logins, double(src.logins)) {
+		t.Errorf("user logins: got %+v, want %+v", dst.logins, src.logins)
+	}
+	if !reflect.DeepEqual(dst.erased, double(src.erased)) {
+		t.Errorf("erased identities: got %+v, want %+v", dst.erased,
+			src.erased)
+	}
+}
+
+func TestImportErased(t *testing.T) {
+	ctx := context.Background()
+	src := testDB()
+	var buf bytes.Buffer
+	err := Export(ctx, src, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Alice was purged and Bob pseudonymized in the database imported
+	// into.
+	dst := &memDB{
+		erased: []ErasedIdentity{
+			{Type: ErasedUserID, Value: "100"},
+			{Type: ErasedEmail, Value: "alice@example.com"},
+			{Type: ErasedLogin, Value: "bob", Pseudonym: "erased-2"},
+		},
+	}
+	err = Import(ctx, dst, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dst.users) != 0 {
+		t.Errorf("erased users imported: %+v", dst.users)
+	}
+	if len(dst.logins) != 0 {
+		t.Errorf("erased logins imported: %+v", dst.logins)
+	}
+	if len(dst.aliases) != 0 {
+		t.Errorf("erased aliases imported: %+v", dst.aliases)
+	}
+	if len(dst.prs) != 0 {
+		t.Errorf("pull requests of erased users imported: %+v", dst.prs)
+	}
+
+	// The pull request of Carol is imported with Bob pseudonymized and
+	// the commit of Alice removed.
+	src.prs[0].User = "carol"
+	src.prs[0].UserID = 102
+	buf.Reset()
+	err = Export(ctx, src, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Import(ctx, dst, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dst.prs) != 1 {
+		t.Fatalf("got %d pull requests, want 1", len(dst.prs))
+	}
+	pr := dst.prs[0]
+	if len(pr.Commits) != 0 {
+		t.Errorf("commits of purged user imported: %+v", pr.Commits)
+	}
+	if len(pr.RequestedReviewers) != 0 {
+		t.Errorf("erased requested reviewers imported: %v",
+			pr.RequestedReviewers)
+	}
+	want := []PullRequestReview{src.prs[0].Reviews[0]}
+	want[0].Author = "erased-2"
+	want[0].AuthorID = 0
+	if !reflect.DeepEqual(pr.Reviews, want) {
+		t.Errorf("reviews: got %+v, want %+v", pr.Reviews, want)
+	}
+}
+
+func TestImportInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		aliases []Alias
+		export  string
+		err     error
+	}{
+		{
+			name: "version 1",
+			export: `{"version":1,"type":"header","data":{"created":1}}
+{"version":1,"type":"alias","data":{"Contributor":"Alice","Type":"login","Value":"alice"}}`,
+		},
+		{
+			name: "same alias",
+			aliases: []Alias{{
+				Contributor: "Alice",
+				Type:        AliasLogin,
+				Value:       "alice",
+			}},
+			export: `{"version":2,"type":"alias","data":{"Contributor":"Alice","Type":"login","Value":"alice"}}`,
+		},
+		{
+			name: "alias of another contributor",
+			aliases: []Alias{{
+				Contributor: "Bob",
+				Type:        AliasLogin,
+				Value:       "alice",
+			}},
+			export: `{"version":2,"type":"alias","data":{"Contributor":"Alice","Type":"login","Value":"alice"}}`,
+			err:    ErrAliasExists,
+		},
+		{
+			name:   "version 0",
+			export: `{"version":0,"type":"header","data":{"created":1}}`,
+			err:    ErrUnsupportedExport,
+		},
+		{
+			name:   "future version",
+			export: `{"version":3,"type":"header","data":{"created":1}}`,
+			err:    ErrUnsupportedExport,
+		},
+		{
+			name:   "unknown type",
+			export: `{"version":2,"type":"invoice","data":{}}`,
+			err:    ErrUnsupportedExport,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := &memDB{aliases: test.aliases}
+			err := Import(context.Background(), db,
+				strings.NewReader(test.export))
+			if !errors.Is(err, test.err) {
+				t.Fatalf("got %v, want %v", err, test.err)
+			}
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -13,10 +15,14 @@ import (
 	"strings"
 	"time"
 
+	"github.com/decred/github-tracker/api"
 	"github.com/decred/github-tracker/database"
 	db "github.com/decred/github-tracker/database/cockroachdb"
 	"github.com/decred/github-tracker/jsonrpc"
+	"github.com/decred/github-tracker/rpc/rpcserver"
 	"github.com/decred/github-tracker/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 // githubtracker application context.
@@ -60,35 +66,148 @@ func run(ctx context.Context) error {
 		}
 	}()
 
-	s, err := server.NewServer(cfg.APIToken)
-	if err != nil {
-		log.Errorf("NewServer failed: %v\n", err)
-		return ctx.Err()
+	var archive *api.Archive
+	if !cfg.NoArchive {
+		archive, err = api.OpenArchive(cfg.ArchiveDir)
+		if err != nil {
+			log.Errorf("OpenArchive failed: %v", err)
+			return err
+		}
+		defer archive.Close()
 	}
 
-	s.DB, err = db.New(cfg.DBHost, cfg.DBRootCert, cfg.DBCert, cfg.DBKey)
+	trackerDB, err := db.New(db.Options{
+		DSN:      cfg.DBDSN,
+		Host:     cfg.DBHost,
+		User:     cfg.DBUser,
+		Password: cfg.DBPass,
+		DBName:   cfg.DBName,
+		SSLMode:  cfg.DBSSLMode,
+		RootCert: cfg.DBRootCert,
+		Cert:     cfg.DBCert,
+		Key:      cfg.DBKey,
+	})
 	if err == database.ErrNoVersionRecord || err == database.ErrWrongVersion {
-		log.Errorf("New DB failed no version, wrong version: %v\n", err)
-		return err
+		// The database version record was either not found or is the
+		// wrong version which means that the database needs to be
+		// built/rebuilt.  Building drops the data fetched from github,
+		// so it is only done when requested.  Reindexing builds the
+		// database itself.
+		switch {
+		case cfg.Build:
+			log.Infof("Building database: %v", err)
+			err = trackerDB.Build(ctx)
+			if err != nil {
+				log.Errorf("DB Build failed: %v\n", err)
+				return err
+			}
+		case cfg.Reindex:
+		default:
+			log.Errorf("New DB failed no version, wrong version: %v "+
+				"(run with --build or --reindex)\n", err)
+			return err
+		}
 	} else if err != nil {
 		log.Errorf("New DB failed: %v\n", err)
 		return err
 	}
-	err = s.DB.Setup()
+	err = trackerDB.Setup(ctx)
 	if err != nil {
 		log.Errorf("DB Setup failed: %v\n", err)
 		return fmt.Errorf("cmsdb setup: %v", err)
 	}
-	defer s.DB.Close()
+	defer trackerDB.Close()
+
+	s, err := server.NewServer(cfg.APIToken, archive, trackerDB)
+	if err != nil {
+		log.Errorf("NewServer failed: %v\n", err)
+		return ctx.Err()
+	}
+	s.CommitRetention = cfg.CommitRetention
+
+	// Keep erased users out of the responses archived from now on.
+	err = s.LoadErasures(ctx)
+	if err != nil {
+		log.Errorf("LoadErasures failed: %v", err)
+		return err
+	}
+
+	// Rebuild the database from the archive and exit when requested.
+	if cfg.Reindex {
+		log.Infof("Reindexing database from %v", cfg.ArchiveDir)
+		err = s.Reindex(ctx)
+		if err != nil {
+			log.Errorf("Reindex failed: %v", err)
+			return err
+		}
+		log.Infof("Reindex complete")
+		return nil
+	}
 
-	jsonRPCServer, err := startJSONRPCServer(cfg, s)
+	// Export or import the database and exit when requested.
+	if cfg.Export != "" {
+		log.Infof("Exporting database to %v", cfg.Export)
+		err = exportDatabase(ctx, s.DB, cfg.Export)
+		if err != nil {
+			log.Errorf("Export failed: %v", err)
+			return err
+		}
+		log.Infof("Export complete")
+		return nil
+	}
+	if cfg.Import != "" {
+		log.Infof("Importing database from %v", cfg.Import)
+		err = importDatabase(ctx, s.DB, cfg.Import)
+		if err != nil {
+			log.Errorf("Import failed: %v", err)
+			return err
+		}
+		log.Infof("Import complete")
+		return nil
+	}
+
+	// Drop the commit messages that outlived the retention period.
+	err = s.ApplyRetention(ctx)
+	if err != nil {
+		log.Errorf("ApplyRetention failed: %v", err)
+		return err
+	}
+
+	metricsServer, err := startMetricsServer(cfg)
+	if err != nil {
+		log.Errorf("unable to create metrics server: %v", err)
+		return err
+	}
+	if metricsServer != nil {
+		defer func() {
+			log.Info("Stopping metrics server...")
+			metricsServer.Shutdown(context.Background())
+			log.Info("Metrics server shutdown")
+		}()
+	}
+
+	rpcs, jsonRPCServer, err := startRPCServers(cfg, s)
 	if err != nil {
 		log.Errorf("unable to create RPC servers: %v", err)
 		return ctx.Err()
 	}
+	if rpcs != nil {
+		defer func() {
+			log.Info("Stopping gRPC server...")
+			rpcs.Stop()
+			log.Info("gRPC server shutdown")
+		}()
+	}
 	if jsonRPCServer != nil {
 		go func() {
 			for range jsonRPCServer.RequestProcessShutdown() {
+				// Let running syncs checkpoint before
+				// shutting down.
+				log.Info("Stopping syncs...")
+				if !s.StopSyncs(cfg.StopTimeout) {
+					log.Warnf("Running syncs did not stop "+
+						"within %v", cfg.StopTimeout)
+				}
 				requestShutdown()
 			}
 		}()
@@ -104,8 +223,47 @@ func run(ctx context.Context) error {
 	return ctx.Err()
 }
 
-func startJSONRPCServer(cfg *config, s *server.Server) (*jsonrpc.Server, error) {
+// exportDatabase writes a database export to the passed file.  The export is
+// written to a temporary file first so that an incomplete export never
+// replaces an existing one.
+func exportDatabase(ctx context.Context, db database.Reader, path string) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	err = database.Export(ctx, db, w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// importDatabase reads a database export from the passed file.
+func importDatabase(ctx context.Context, db database.ReadWriter, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return database.Import(ctx, db, bufio.NewReader(f))
+}
+
+func startRPCServers(cfg *config, s *server.Server) (*grpc.Server, *jsonrpc.Server, error) {
 	var (
+		rpcServer     *grpc.Server
 		jsonrpcServer *jsonrpc.Server
 		jsonrpcListen listenFunc
 		keyPair       tls.Certificate
@@ -114,41 +272,124 @@ func startJSONRPCServer(cfg *config, s *server.Server) (*jsonrpc.Server, error)
 
 	keyPair, err = openRPCKeyPair(cfg)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Change the standard net.Listen function to the tls one.
 	tlsConfig := &tls.Config{
 		Certificates: []tls.Certificate{keyPair},
 		MinVersion:   tls.VersionTLS12,
-		NextProtos:   []string{"h2"}, // HTTP/2 over TLS
+	}
+	if cfg.RPCClientCA != "" {
+		clientCAs, err := readCertPool(cfg.RPCClientCA)
+		if err != nil {
+			return nil, nil, err
+		}
+		tlsConfig.ClientCAs = clientCAs
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
 	}
 	jsonrpcListen = func(net string, laddr string) (net.Listener, error) {
 		return tls.Listen(net, laddr, tlsConfig)
 	}
 
-	if cfg.RPCUsername == "" || cfg.RPCPassword == "" {
-		log.Info("JSON-RPC server disabled (requires username and password)")
-	} else if len(cfg.LegacyRPCListeners) != 0 {
-		listeners := makeListeners(cfg.LegacyRPCListeners, jsonrpcListen)
-		if len(listeners) == 0 {
-			err := errors.New("failed to create listeners for JSON-RPC server")
-			return nil, err
+	var users []jsonrpc.User
+	if cfg.RPCUsersFile != "" {
+		users, err = readRPCUsers(cfg.RPCUsersFile)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	clientCerts := make([]jsonrpc.ClientCert, 0, len(cfg.RPCClientCerts))
+	for _, mapping := range cfg.RPCClientCerts {
+		cc, err := jsonrpc.ParseClientCert(mapping)
+		if err != nil {
+			return nil, nil, err
+		}
+		clientCerts = append(clientCerts, cc)
+	}
+
+	opts := jsonrpc.Options{
+		Username:            cfg.RPCUsername,
+		Password:            cfg.RPCPassword,
+		Users:               users,
+		ClientCerts:         clientCerts,
+		MaxPOSTClients:      cfg.LegacyRPCMaxClients,
+		MaxWebsocketClients: cfg.LegacyRPCMaxWebsockets,
+	}
+
+	if (cfg.RPCUsername == "" || cfg.RPCPassword == "") && len(users) == 0 &&
+		len(clientCerts) == 0 {
+		log.Info("RPC servers disabled (requires username and " +
+			"password, a users file or client certificates)")
+	} else {
+		if len(cfg.GRPCListeners) != 0 {
+			listeners := makeListeners(cfg.GRPCListeners, net.Listen)
+			if len(listeners) == 0 {
+				err := errors.New("failed to create listeners for gRPC server")
+				return nil, nil, err
+			}
+			// Only gRPC negotiates HTTP/2.  The JSON-RPC listener
+			// must stay on HTTP/1.1 for websocket upgrades.
+			grpcTLSConfig := tlsConfig.Clone()
+			grpcTLSConfig.NextProtos = []string{"h2", "http/1.1"}
+			auth := jsonrpc.NewAuthenticator(&opts, s)
+			creds := credentials.NewTLS(grpcTLSConfig)
+			rpcServer = rpcserver.NewServer(s, auth, grpc.Creds(creds))
+			for _, lis := range listeners {
+				lis := lis
+				go func() {
+					log.Infof("gRPC server listening on %s",
+						lis.Addr())
+					err := rpcServer.Serve(lis)
+					log.Tracef("Finished serving gRPC: %v", err)
+				}()
+			}
 		}
-		opts := jsonrpc.Options{
-			Username:       cfg.RPCUsername,
-			Password:       cfg.RPCPassword,
-			MaxPOSTClients: cfg.LegacyRPCMaxClients,
+
+		if len(cfg.LegacyRPCListeners) != 0 {
+			listeners := makeListeners(cfg.LegacyRPCListeners, jsonrpcListen)
+			if len(listeners) == 0 {
+				err := errors.New("failed to create listeners for JSON-RPC server")
+				return nil, nil, err
+			}
+			jsonrpcServer = jsonrpc.NewServer(&opts, listeners, s)
 		}
-		jsonrpcServer = jsonrpc.NewServer(&opts, listeners, *s)
 	}
 
 	// Error when neither the GRPC nor JSON-RPC servers can be started.
-	if jsonrpcServer == nil {
-		return nil, errors.New("no suitable RPC services can be started")
+	if rpcServer == nil && jsonrpcServer == nil {
+		return nil, nil, errors.New("no suitable RPC services can be started")
 	}
 
-	return jsonrpcServer, nil
+	return rpcServer, jsonrpcServer, nil
+}
+
+// readRPCUsers reads the JSON-RPC users from the users file at path.
+func readRPCUsers(path string) ([]jsonrpc.User, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	users, err := jsonrpc.ReadUsers(f)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return users, nil
+}
+
+// readCertPool reads the PEM encoded CA certificates in the file at path.
+func readCertPool(path string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%v: no PEM encoded certificates", path)
+	}
+	return pool, nil
 }
 
 // openRPCKeyPair creates or loads the RPC TLS keypair specified by the
@@ -1,6 +1,6 @@
 module github.com/decred/github-tracker
 
-go 1.14
+go 1.21
 
 require (
 	github.com/decred/dcrd/certgen v1.1.0
@@ -14,5 +14,37 @@ require (
 	github.com/jinzhu/gorm v1.9.12
 	github.com/jrick/logrotate v1.0.0
 	github.com/pkg/errors v0.9.1
-	golang.org/x/oauth2 v0.0.0-20200107190931-bf48bf16ab8d
+	github.com/prometheus/client_golang v1.19.1
+	golang.org/x/oauth2 v0.18.0
+	google.golang.org/grpc v1.64.1
+	google.golang.org/protobuf v1.34.2
+)
+
+require (
+	github.com/agl/ed25519 v0.0.0-20170116200512-5312a6153412 // indirect
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/dchest/blake256 v1.0.0 // indirect
+	github.com/decred/base58 v1.0.0 // indirect
+	github.com/decred/dcrd/chaincfg v1.5.1 // indirect
+	github.com/decred/dcrd/chaincfg/chainhash v1.0.2 // indirect
+	github.com/decred/dcrd/chaincfg/v2 v2.0.2 // indirect
+	github.com/decred/dcrd/crypto/blake256 v1.0.0 // indirect
+	github.com/decred/dcrd/dcrec v1.0.0 // indirect
+	github.com/decred/dcrd/dcrec/edwards v1.0.0 // indirect
+	github.com/decred/dcrd/dcrec/secp256k1 v1.0.2 // indirect
+	github.com/decred/dcrd/dcrutil/v2 v2.0.0 // indirect
+	github.com/decred/dcrd/wire v1.2.0 // indirect
+	github.com/jinzhu/inflection v1.0.0 // indirect
+	github.com/lib/pq v1.1.1 // indirect
+	github.com/prometheus/client_model v0.5.0 // indirect
+	github.com/prometheus/common v0.48.0 // indirect
+	github.com/prometheus/procfs v0.12.0 // indirect
+	golang.org/x/crypto v0.24.0 // indirect
+	golang.org/x/net v0.26.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+	golang.org/x/text v0.16.0 // indirect
+	golang.org/x/xerrors v0.0.0-20220907171357-04be3eba64a2 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240318140521-94a12d6c2237 // indirect
 )
@@ -0,0 +1,180 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package jsonrpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/dcrjson/v3"
+)
+
+func TestIsNotification(t *testing.T) {
+	id := interface{}(1.0)
+	tests := []struct {
+		name string
+		req  dcrjson.Request
+		want bool
+	}{
+		{
+			name: "2.0 request",
+			req:  dcrjson.Request{Jsonrpc: "2.0", ID: id},
+		},
+		{
+			name: "2.0 notification",
+			req:  dcrjson.Request{Jsonrpc: "2.0"},
+			want: true,
+		},
+		{
+			name: "1.0 request",
+			req:  dcrjson.Request{Jsonrpc: "1.0", ID: id},
+		},
+		{
+			name: "1.0 without id",
+			req:  dcrjson.Request{Jsonrpc: "1.0"},
+		},
+		{
+			name: "no version",
+			req:  dcrjson.Request{},
+		},
+	}
+	for _, test := range tests {
+		if got := isNotification(&test.req); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+// testReply is the part of a JSON-RPC response compared by the tests.
+type testReply struct {
+	ID    interface{} `json:"id"`
+	Error *struct {
+		Code dcrjson.RPCErrorCode `json:"code"`
+	} `json:"error"`
+}
+
+func TestPostClientRPC(t *testing.T) {
+	// The requests are rejected before any server is needed: the methods
+	// are either unknown or the caller is not authenticated.
+	var (
+		notFound  = dcrjson.ErrRPCMethodNotFound.Code
+		forbidden = ErrRPCForbidden
+		invalid   = dcrjson.ErrRPCInvalidRequest.Code
+	)
+	tests := []struct {
+		name    string
+		body    string
+		status  int
+		ids     []interface{}
+		codes   []dcrjson.RPCErrorCode
+		isBatch bool
+	}{
+		{
+			name:   "request",
+			body:   `{"jsonrpc":"2.0","id":1,"method":"nosuch"}`,
+			status: http.StatusOK,
+			ids:    []interface{}{1.0},
+			codes:  []dcrjson.RPCErrorCode{notFound},
+		},
+		{
+			name:   "notification",
+			body:   `{"jsonrpc":"2.0","method":"nosuch"}`,
+			status: http.StatusNoContent,
+		},
+		{
+			name:   "1.0 without id",
+			body:   `{"jsonrpc":"1.0","method":"nosuch","id":null}`,
+			status: http.StatusOK,
+			ids:    []interface{}{nil},
+			codes:  []dcrjson.RPCErrorCode{notFound},
+		},
+		{
+			name: "batch",
+			body: `[
+				{"jsonrpc":"2.0","id":1,"method":"nosuch"},
+				{"jsonrpc":"2.0","method":"nosuch"},
+				{"jsonrpc":"2.0","id":"b","method":"version"},
+				1,
+				{"jsonrpc":"1.0","id":null,"method":"nosuch"}
+			]`,
+			status:  http.StatusOK,
+			ids:     []interface{}{1.0, "b", nil, nil},
+			codes:   []dcrjson.RPCErrorCode{notFound, forbidden, invalid, notFound},
+			isBatch: true,
+		},
+		{
+			name: "batch of notifications",
+			body: ` [
+				{"jsonrpc":"2.0","method":"nosuch"},
+				{"jsonrpc":"2.0","method":"version"}
+			]`,
+			status: http.StatusNoContent,
+		},
+		{
+			name:   "empty batch",
+			body:   `[]`,
+			status: http.StatusOK,
+			ids:    []interface{}{nil},
+			codes:  []dcrjson.RPCErrorCode{invalid},
+		},
+		{
+			name:   "invalid batch",
+			body:   `[{"jsonrpc":"2.0"`,
+			status: http.StatusOK,
+			ids:    []interface{}{nil},
+			codes:  []dcrjson.RPCErrorCode{invalid},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &Server{}
+			r := httptest.NewRequest(http.MethodPost, "/",
+				strings.NewReader(test.body))
+			w := httptest.NewRecorder()
+			s.postClientRPC(w, r)
+
+			if w.Code != test.status {
+				t.Fatalf("got status %d, want %d", w.Code, test.status)
+			}
+			if test.status == http.StatusNoContent {
+				if w.Body.Len() != 0 {
+					t.Fatalf("unexpected reply %s", w.Body)
+				}
+				return
+			}
+
+			var replies []testReply
+			var err error
+			if test.isBatch {
+				err = json.Unmarshal(w.Body.Bytes(), &replies)
+			} else {
+				replies = make([]testReply, 1)
+				err = json.Unmarshal(w.Body.Bytes(), &replies[0])
+			}
+			if err != nil {
+				t.Fatalf("invalid reply %s: %v", w.Body, err)
+			}
+			var ids []interface{}
+			var codes []dcrjson.RPCErrorCode
+			for _, reply := range replies {
+				ids = append(ids, reply.ID)
+				if reply.Error == nil {
+					t.Fatalf("reply %s without error", w.Body)
+				}
+				codes = append(codes, reply.Error.Code)
+			}
+			if !reflect.DeepEqual(ids, test.ids) {
+				t.Fatalf("got ids %v, want %v", ids, test.ids)
+			}
+			if !reflect.DeepEqual(codes, test.codes) {
+				t.Fatalf("got codes %v, want %v", codes, test.codes)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package jsonrpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"strings"
+)
+
+// ClientCert grants a role to the verified TLS client certificates with the
+// passed name as their subject common name or one of their DNS, email or URI
+// subject alternative names.
+type ClientCert struct {
+	Name string
+	Role Role
+}
+
+// ParseClientCert parses a client certificate mapping in the form name:role.
+func ParseClientCert(s string) (ClientCert, error) {
+	i := strings.LastIndex(s, ":")
+	if i <= 0 {
+		return ClientCert{}, fmt.Errorf("client certificate %q is not "+
+			"in the form name:role", s)
+	}
+	role, err := ParseRole(s[i+1:])
+	if err != nil {
+		return ClientCert{}, err
+	}
+	return ClientCert{Name: s[:i], Role: role}, nil
+}
+
+// certNames returns the names a client certificate may be mapped by, starting
+// with its subject common name.
+func certNames(cert *x509.Certificate) []string {
+	names := make([]string, 0, 1+len(cert.DNSNames)+
+		len(cert.EmailAddresses)+len(cert.URIs))
+	if cert.Subject.CommonName != "" {
+		names = append(names, cert.Subject.CommonName)
+	}
+	names = append(names, cert.DNSNames...)
+	names = append(names, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	return names
+}
+
+// clientCertIdentity returns the identity of the client that presented a
+// verified certificate with a mapped name on the passed connection, or nil
+// when there is none.
+func (a *Authenticator) clientCertIdentity(state *tls.ConnectionState) *identity {
+	if state == nil || len(state.VerifiedChains) == 0 ||
+		len(a.clientCerts) == 0 {
+		return nil
+	}
+	leaf := state.VerifiedChains[0][0]
+	for _, name := range certNames(leaf) {
+		for _, cc := range a.clientCerts {
+			if cc.Name == name {
+				return &identity{name: "cert:" + name, role: cc.Role}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package jsonrpc
+
+import "testing"
+
+func TestParseClientCert(t *testing.T) {
+	tests := []struct {
+		s       string
+		cert    ClientCert
+		wantErr bool
+	}{
+		{
+			s:    "ci.example.com:operator",
+			cert: ClientCert{Name: "ci.example.com", Role: RoleOperator},
+		},
+		{
+			s:    "spiffe://example.com/tracker:admin",
+			cert: ClientCert{Name: "spiffe://example.com/tracker", Role: RoleAdmin},
+		},
+		{
+			s:    "ops@example.com:reader",
+			cert: ClientCert{Name: "ops@example.com", Role: RoleReader},
+		},
+		{s: "ci.example.com", wantErr: true},
+		{s: ":admin", wantErr: true},
+		{s: "ci.example.com:", wantErr: true},
+		{s: "ci.example.com:root", wantErr: true},
+	}
+	for _, test := range tests {
+		cert, err := ParseClientCert(test.s)
+		if (err != nil) != test.wantErr {
+			t.Errorf("ParseClientCert(%q): got error %v, want error %v",
+				test.s, err, test.wantErr)
+			continue
+		}
+		if cert != test.cert {
+			t.Errorf("ParseClientCert(%q): got %+v, want %+v", test.s,
+				cert, test.cert)
+		}
+	}
+}
@@ -6,8 +6,14 @@ package jsonrpc
 
 // Options contains the required options for running the legacy RPC server.
 type Options struct {
-	Username string
+	Username string // Username of the admin user, may be empty
 	Password string
+	Users    []User // Additional users along with their roles
+
+	// ClientCerts maps verified TLS client certificates to roles.
+	// Clients presenting a mapped certificate do not need to send an
+	// Authorization header.
+	ClientCerts []ClientCert
 
 	MaxPOSTClients      int64
 	MaxWebsocketClients int64
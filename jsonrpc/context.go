@@ -19,3 +19,15 @@ func remoteAddr(ctx context.Context) string {
 	}
 	return v.(string)
 }
+
+func withIdentity(parent context.Context, id *identity) context.Context {
+	return context.WithValue(parent, contextKey("identity"), id)
+}
+
+func callerIdentity(ctx context.Context) *identity {
+	v := ctx.Value(contextKey("identity"))
+	if v == nil {
+		return nil
+	}
+	return v.(*identity)
+}
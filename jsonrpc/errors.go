@@ -12,6 +12,10 @@ import (
 	"github.com/decred/dcrwallet/errors/v2"
 )
 
+// ErrRPCForbidden is the error code returned when the caller is not granted
+// the role required by a method.
+const ErrRPCForbidden dcrjson.RPCErrorCode = -403
+
 func convertError(err error) *dcrjson.RPCError {
 	if err, ok := err.(*dcrjson.RPCError); ok {
 		return err
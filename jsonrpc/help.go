@@ -0,0 +1,85 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/decred/dcrd/dcrjson/v3"
+	"github.com/decred/github-tracker/jsonrpc/types"
+)
+
+// helpDescs describes every method listed by help.  The usage of a method is
+// generated from its registered command type.
+var helpDescs = map[string]string{
+	"update":           "Syncs the pull requests of every repository of an organization from GitHub.",
+	"userinformation":  "Returns the pull requests, reviews and commits of a user or contributor for a month, or its frozen snapshot.",
+	"listpullrequests": "Returns a page of the pull requests matching a filter along with the cursor of the next page.",
+	"addalias":         "Assigns a login or email to a contributor.",
+	"removealias":      "Removes a login or email from its contributor.",
+	"listaliases":      "Lists the aliases of a contributor, or all aliases when no contributor is passed.",
+	"prhistory":        "Returns the changes sync applied to a pull request, its reviews and commits.",
+	"snapshotmonth":    "Freezes the user information of every contributor of an organization for a month that has ended.",
+	"orgsummary":       "Returns the monthly totals of all users of an organization.",
+	"usersummary":      "Returns the monthly totals of a user.",
+	"purgeuser":        "Removes all data tied to a user.",
+	"pseudonymizeuser": "Replaces a user with a random pseudonym in all data.",
+	"createapikey":     "Creates an API key with the read, sync or admin scope.  The key is only returned once.",
+	"revokeapikey":     "Removes an API key by its ID.",
+	"listapikeys":      "Lists all API keys without the keys themselves.",
+	"help":             "Lists the usage of every method, or describes a single method.",
+	"version":          "Returns the JSON-RPC API version and the version of the server build.",
+	"status":           "Returns the uptime of the server, whether the database is reachable, the GitHub rate limit and when every organization was last synced.",
+	"stop":             "Stops accepting syncs, waits for running syncs to checkpoint and shuts down the server.",
+}
+
+func init() {
+	// help is registered here rather than with the other handlers since
+	// it refers to the handlers map.
+	handlers["help"] = handler{fn: (*Server).help, role: RoleReader}
+}
+
+// methodHelp returns the usage, description and required role of a method.
+func methodHelp(method string, h *handler) (string, error) {
+	usage, err := dcrjson.MethodUsageText(types.Method(method))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s\n\n%s\n\nRequires the %v role.", usage,
+		helpDescs[method], h.role), nil
+}
+
+// help returns the usage of every method, one per line, or the help of the
+// requested method.
+func (s *Server) help(ctx context.Context, icmd interface{}) (interface{}, error) {
+	cmd := icmd.(*types.HelpCmd)
+
+	if cmd.Command != nil && *cmd.Command != "" {
+		h, ok := handlers[*cmd.Command]
+		if !ok {
+			return nil, rpcErrorf(dcrjson.ErrRPCInvalidParameter,
+				"no help for method %q", *cmd.Command)
+		}
+		return methodHelp(*cmd.Command, &h)
+	}
+
+	methods := make([]string, 0, len(handlers))
+	for method := range handlers {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	usages := make([]string, 0, len(methods))
+	for _, method := range methods {
+		usage, err := dcrjson.MethodUsageText(types.Method(method))
+		if err != nil {
+			return nil, err
+		}
+		usages = append(usages, usage)
+	}
+	return strings.Join(usages, "\n"), nil
+}
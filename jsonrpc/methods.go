@@ -8,10 +8,16 @@ package jsonrpc
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"runtime/debug"
+	"strings"
 
 	"github.com/decred/dcrd/dcrjson/v3"
+	dcrdtypes "github.com/decred/dcrd/rpc/jsonrpc/types"
+	"github.com/decred/github-tracker/database"
 	"github.com/decred/github-tracker/jsonrpc/types"
+	"github.com/decred/github-tracker/server"
 )
 
 // API version constants
@@ -25,8 +31,24 @@ const (
 // the registered rpc handlers
 var handlers = map[string]handler{
 	// Reference implementation wallet methods (implemented)
-	"update":          {fn: (*Server).update},
-	"userinformation": {fn: (*Server).userInformation},
+	"update":           {fn: (*Server).update, role: RoleOperator},
+	"userinformation":  {read: userInformation, role: RoleReader},
+	"listpullrequests": {read: listPullRequests, role: RoleReader},
+	"addalias":         {fn: (*Server).addAlias, role: RoleOperator},
+	"removealias":      {fn: (*Server).removeAlias, role: RoleOperator},
+	"listaliases":      {read: listAliases, role: RoleReader},
+	"prhistory":        {read: prHistory, role: RoleReader},
+	"snapshotmonth":    {fn: (*Server).snapshotMonth, role: RoleOperator},
+	"orgsummary":       {read: orgSummary, role: RoleReader},
+	"usersummary":      {read: userSummary, role: RoleReader},
+	"purgeuser":        {fn: (*Server).purgeUser, role: RoleAdmin},
+	"pseudonymizeuser": {fn: (*Server).pseudonymizeUser, role: RoleAdmin},
+	"createapikey":     {fn: (*Server).createAPIKey, role: RoleAdmin},
+	"revokeapikey":     {fn: (*Server).revokeAPIKey, role: RoleAdmin},
+	"listapikeys":      {read: listAPIKeys, role: RoleAdmin},
+	"version":          {fn: (*Server).version, role: RoleReader},
+	"status":           {fn: (*Server).status, role: RoleReader},
+	"stop":             {fn: (*Server).stop, role: RoleAdmin},
 }
 
 // lazyHandler is a closure over a requestHandler or passthrough request with
@@ -39,18 +61,23 @@ type lazyHandler func() (interface{}, *dcrjson.RPCError)
 // (optional) consensus RPC server.  If no handlers are found and the
 // chainClient is not nil, the returned handler performs RPC passthrough.
 func lazyApplyHandler(s *Server, ctx context.Context, request *dcrjson.Request) lazyHandler {
+	countCall(request.Method)
 	handlerData, ok := handlers[request.Method]
 	if !ok {
 		return func() (interface{}, *dcrjson.RPCError) {
-			return nil, dcrjson.ErrRPCInvalidRequest
+			return nil, dcrjson.ErrRPCMethodNotFound
+		}
+	}
+	if jsonErr := handlerData.checkRole(ctx, request.Method); jsonErr != nil {
+		return func() (interface{}, *dcrjson.RPCError) {
+			return nil, jsonErr
 		}
 	}
 
 	return func() (interface{}, *dcrjson.RPCError) {
 		params, err := dcrjson.ParseParams(types.Method(request.Method), request.Params)
 		if err != nil {
-			fmt.Println(err)
-			return nil, dcrjson.ErrRPCInvalidRequest
+			return nil, rpcError(dcrjson.ErrRPCInvalidParams.Code, err)
 		}
 
 		defer func() {
@@ -58,12 +85,33 @@ func lazyApplyHandler(s *Server, ctx context.Context, request *dcrjson.Request)
 				log.Warnf("Canceled RPC method %v invoked by %v: %v", request.Method, remoteAddr(ctx), err)
 			}
 		}()
-		resp, err := handlerData.fn(s, ctx, params)
-		if err != nil {
-			return nil, convertError(err)
-		}
-		return resp, nil
+		return handlerData.call(s, ctx, params)
+	}
+}
+
+// checkRole returns an error when the caller is not granted the role required
+// by the handler of the method.
+func (h *handler) checkRole(ctx context.Context, method string) *dcrjson.RPCError {
+	if id := callerIdentity(ctx); id == nil || id.role < h.role {
+		return rpcErrorf(ErrRPCForbidden, "method %v requires the %v role",
+			method, h.role)
 	}
+	return nil
+}
+
+// call executes the handler with the parsed parameters of a request.
+func (h *handler) call(s *Server, ctx context.Context, params interface{}) (interface{}, *dcrjson.RPCError) {
+	var resp interface{}
+	var err error
+	if h.read != nil {
+		resp, err = h.read(s.reader, ctx, params)
+	} else {
+		resp, err = h.fn(s, ctx, params)
+	}
+	if err != nil {
+		return nil, convertError(err)
+	}
+	return resp, nil
 }
 
 // makeResponse makes the JSON-RPC response struct for the result and error
@@ -107,12 +155,288 @@ func (s *Server) update(ctx context.Context, icmd interface{}) (interface{}, err
 
 // update removes an unconfirmed transaction and all dependent
 // transactions from the wallet.
-func (s *Server) userInformation(ctx context.Context, icmd interface{}) (interface{}, error) {
+func userInformation(r *server.Reader, ctx context.Context, icmd interface{}) (interface{}, error) {
 	cmd := icmd.(*types.UserInformationCmd)
 
-	userInfoResult, err := s.server.UserInformation(ctx, cmd.Org, cmd.User, cmd.Year, cmd.Month)
+	if cmd.Snapshot != nil && *cmd.Snapshot {
+		userInfoResult, err := r.UserSnapshot(ctx, cmd.Org, cmd.User, cmd.Year, cmd.Month)
+		if errors.Is(err, server.ErrInvalidUser) {
+			return nil, rpcError(dcrjson.ErrRPCInvalidParams.Code, err)
+		}
+		if errors.Is(err, database.ErrSnapshotNotFound) {
+			return nil, rpcError(dcrjson.ErrRPCInvalidParameter, err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return userInfoResult, nil
+	}
+
+	userInfoResult, err := r.UserInformation(ctx, cmd.Org, cmd.User, cmd.Year, cmd.Month)
+	if errors.Is(err, server.ErrInvalidUser) {
+		return nil, rpcError(dcrjson.ErrRPCInvalidParams.Code, err)
+	}
 	if err != nil {
 		return nil, err
 	}
 	return userInfoResult, err
 }
+
+// listPullRequests returns a page of pull requests matching the requested
+// filter.
+func listPullRequests(r *server.Reader, ctx context.Context, icmd interface{}) (interface{}, error) {
+	cmd := icmd.(*types.ListPullRequestsCmd)
+
+	var filter types.PullRequestFilter
+	if cmd.Filter != nil {
+		filter = *cmd.Filter
+	}
+	var cursor string
+	if cmd.Cursor != nil {
+		cursor = *cmd.Cursor
+	}
+	var limit int
+	if cmd.Limit != nil {
+		limit = *cmd.Limit
+	}
+
+	result, err := r.ListPullRequests(ctx, filter, cursor, limit)
+	if errors.Is(err, server.ErrInvalidFilter) ||
+		errors.Is(err, database.ErrInvalidCursor) {
+		return nil, rpcError(dcrjson.ErrRPCInvalidParameter, err)
+	} else if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// addAlias assigns a login or email to a contributor.
+func (s *Server) addAlias(ctx context.Context, icmd interface{}) (interface{}, error) {
+	cmd := icmd.(*types.AddAliasCmd)
+
+	err := s.server.AddAlias(ctx, cmd.Contributor, cmd.Type, cmd.Value)
+	if errors.Is(err, server.ErrInvalidAlias) ||
+		errors.Is(err, database.ErrAliasExists) {
+		return nil, rpcError(dcrjson.ErrRPCInvalidParameter, err)
+	}
+	return nil, err
+}
+
+// removeAlias removes a login or email from its contributor.
+func (s *Server) removeAlias(ctx context.Context, icmd interface{}) (interface{}, error) {
+	cmd := icmd.(*types.RemoveAliasCmd)
+
+	err := s.server.RemoveAlias(ctx, cmd.Type, cmd.Value)
+	if errors.Is(err, server.ErrInvalidAlias) ||
+		errors.Is(err, database.ErrAliasNotFound) {
+		return nil, rpcError(dcrjson.ErrRPCInvalidParameter, err)
+	}
+	return nil, err
+}
+
+// listAliases lists the aliases of a contributor, or all aliases.
+func listAliases(r *server.Reader, ctx context.Context, icmd interface{}) (interface{}, error) {
+	cmd := icmd.(*types.ListAliasesCmd)
+
+	var contributor string
+	if cmd.Contributor != nil {
+		contributor = *cmd.Contributor
+	}
+	return r.ListAliases(ctx, contributor)
+}
+
+// prHistory returns the change history of a pull request.
+func prHistory(r *server.Reader, ctx context.Context, icmd interface{}) (interface{}, error) {
+	cmd := icmd.(*types.PRHistoryCmd)
+
+	result, err := r.PullRequestHistory(ctx, cmd.URL)
+	if errors.Is(err, database.ErrNoPullRequestFound) {
+		return nil, rpcError(dcrjson.ErrRPCInvalidParameter, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// snapshotMonth freezes the user information of all users for a month.
+func (s *Server) snapshotMonth(ctx context.Context, icmd interface{}) (interface{}, error) {
+	cmd := icmd.(*types.SnapshotMonthCmd)
+
+	result, err := s.server.SnapshotMonth(ctx, cmd.Org, cmd.Year, cmd.Month)
+	if errors.Is(err, server.ErrInvalidMonth) ||
+		errors.Is(err, database.ErrSnapshotExists) {
+		return nil, rpcError(dcrjson.ErrRPCInvalidParameter, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// orgSummary returns the monthly totals of all users of an organization.
+func orgSummary(r *server.Reader, ctx context.Context, icmd interface{}) (interface{}, error) {
+	cmd := icmd.(*types.OrgSummaryCmd)
+
+	result, err := r.OrgSummary(ctx, cmd.Org, cmd.Year, cmd.Month)
+	if errors.Is(err, server.ErrInvalidMonth) {
+		return nil, rpcError(dcrjson.ErrRPCInvalidParameter, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// userSummary returns the monthly totals of a user.
+func userSummary(r *server.Reader, ctx context.Context, icmd interface{}) (interface{}, error) {
+	cmd := icmd.(*types.UserSummaryCmd)
+
+	result, err := r.UserSummary(ctx, cmd.Org, cmd.User, cmd.Year, cmd.Month)
+	if errors.Is(err, server.ErrInvalidUser) {
+		return nil, rpcError(dcrjson.ErrRPCInvalidParams.Code, err)
+	}
+	if errors.Is(err, server.ErrInvalidMonth) {
+		return nil, rpcError(dcrjson.ErrRPCInvalidParameter, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// purgeUser removes all data tied to a user.
+func (s *Server) purgeUser(ctx context.Context, icmd interface{}) (interface{}, error) {
+	cmd := icmd.(*types.PurgeUserCmd)
+	if strings.TrimSpace(cmd.User) == "" {
+		return nil, rpcError(dcrjson.ErrRPCInvalidParams.Code,
+			errors.New("empty user"))
+	}
+
+	result, err := s.server.PurgeUser(ctx, cmd.User)
+	if errors.Is(err, server.ErrInvalidUser) ||
+		errors.Is(err, database.ErrInvalidIdentity) {
+		return nil, rpcError(dcrjson.ErrRPCInvalidParams.Code, err)
+	}
+	if errors.Is(err, database.ErrUserNotFound) {
+		return nil, rpcError(dcrjson.ErrRPCInvalidParameter, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// pseudonymizeUser replaces a user with a random pseudonym.
+func (s *Server) pseudonymizeUser(ctx context.Context, icmd interface{}) (interface{}, error) {
+	cmd := icmd.(*types.PseudonymizeUserCmd)
+	if strings.TrimSpace(cmd.User) == "" {
+		return nil, rpcError(dcrjson.ErrRPCInvalidParams.Code,
+			errors.New("empty user"))
+	}
+
+	result, err := s.server.PseudonymizeUser(ctx, cmd.User)
+	if errors.Is(err, server.ErrInvalidUser) ||
+		errors.Is(err, database.ErrInvalidIdentity) {
+		return nil, rpcError(dcrjson.ErrRPCInvalidParams.Code, err)
+	}
+	if errors.Is(err, database.ErrUserNotFound) {
+		return nil, rpcError(dcrjson.ErrRPCInvalidParameter, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// createAPIKey creates an API key with a scope.
+func (s *Server) createAPIKey(ctx context.Context, icmd interface{}) (interface{}, error) {
+	cmd := icmd.(*types.CreateAPIKeyCmd)
+
+	var expires int64
+	if cmd.Expires != nil {
+		expires = *cmd.Expires
+	}
+	result, err := s.server.CreateAPIKey(ctx, cmd.Name, cmd.Scope, expires)
+	if errors.Is(err, server.ErrInvalidAPIKey) {
+		return nil, rpcError(dcrjson.ErrRPCInvalidParameter, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// revokeAPIKey removes an API key.
+func (s *Server) revokeAPIKey(ctx context.Context, icmd interface{}) (interface{}, error) {
+	cmd := icmd.(*types.RevokeAPIKeyCmd)
+
+	err := s.server.RevokeAPIKey(ctx, cmd.ID)
+	if errors.Is(err, database.ErrAPIKeyNotFound) {
+		return nil, rpcError(dcrjson.ErrRPCInvalidParameter, err)
+	}
+	return nil, err
+}
+
+// listAPIKeys lists all API keys without the keys themselves.
+func listAPIKeys(r *server.Reader, ctx context.Context, icmd interface{}) (interface{}, error) {
+	return r.ListAPIKeys(ctx)
+}
+
+// version returns the JSON-RPC API version and the version of the server
+// build.
+func (s *Server) version(ctx context.Context, icmd interface{}) (interface{}, error) {
+	return map[string]dcrdtypes.VersionResult{
+		"githubtrackerjsonrpcapi": {
+			VersionString: jsonrpcSemverString,
+			Major:         jsonrpcSemverMajor,
+			Minor:         jsonrpcSemverMinor,
+			Patch:         jsonrpcSemverPatch,
+		},
+		"githubtracker": buildVersion(),
+	}, nil
+}
+
+// buildVersion returns the version of the running build from its module build
+// information.  The build metadata holds the VCS revision the build was made
+// from unless the module version sets it.
+func buildVersion() dcrdtypes.VersionResult {
+	v := dcrdtypes.VersionResult{VersionString: "(devel)"}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return v
+	}
+	v.VersionString = info.Main.Version
+
+	semver := strings.TrimPrefix(info.Main.Version, "v")
+	if i := strings.IndexByte(semver, '+'); i != -1 {
+		v.BuildMetadata = semver[i+1:]
+		semver = semver[:i]
+	}
+	if i := strings.IndexByte(semver, '-'); i != -1 {
+		v.Prerelease = semver[i+1:]
+		semver = semver[:i]
+	}
+	fmt.Sscanf(semver, "%d.%d.%d", &v.Major, &v.Minor, &v.Patch)
+
+	if v.BuildMetadata == "" {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				v.BuildMetadata = setting.Value
+			}
+		}
+	}
+	return v
+}
+
+// stop replies that the server is stopping.  Shutdown is requested once the
+// reply is written.
+func (s *Server) stop(ctx context.Context, icmd interface{}) (interface{}, error) {
+	return "github-tracker stopping", nil
+}
+
+// status returns the uptime of the server, whether the database is reachable,
+// the GitHub rate limit and when every organization was last synced.
+func (s *Server) status(ctx context.Context, icmd interface{}) (interface{}, error) {
+	return s.server.Status(ctx)
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package jsonrpc
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	rpcCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "githubtracker",
+		Subsystem: "rpc",
+		Name:      "calls_total",
+		Help:      "JSON-RPC, REST and gRPC calls by method.",
+	}, []string{"method"})
+
+	throttledTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "githubtracker",
+		Subsystem: "rpc",
+		Name:      "throttled_total",
+		Help:      "Requests rejected with HTTP 429 by the concurrent client limits.",
+	})
+)
+
+// RegisterMetrics registers the Prometheus collectors of the package with r.
+func RegisterMetrics(r prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{rpcCalls, throttledTotal} {
+		if err := r.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// countCall counts a call of method.  Methods without a handler are counted
+// as unknown so that clients cannot grow the label values.
+func countCall(method string) {
+	if _, ok := handlers[method]; !ok {
+		method = "unknown"
+	}
+	rpcCalls.WithLabelValues(method).Inc()
+}
+
+// CountCall counts a call of method by another RPC server, such as the gRPC
+// server, which passes the full name of the called method.  Only methods the
+// server registers may be passed so that clients cannot grow the label values.
+func CountCall(method string) {
+	rpcCalls.WithLabelValues(method).Inc()
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package jsonrpc
+
+// openAPIDocument is the OpenAPI description of the REST gateway served at
+// /api/v1/openapi.json.  It must be kept in sync with restRoutes.
+const openAPIDocument = `{
+  "openapi": "3.0.3",
+  "info": {
+    "title": "github-tracker REST API",
+    "version": "1.0.0",
+    "description": "REST gateway to the github-tracker JSON-RPC methods."
+  },
+  "servers": [{"url": "/api/v1"}],
+  "security": [{"basicAuth": []}, {"bearerAuth": []}],
+  "paths": {
+    "/users/{user}/stats": {
+      "get": {
+        "summary": "User information of a month (userinformation, reader role)",
+        "operationId": "userStats",
+        "parameters": [
+          {"name": "user", "in": "path", "required": true, "description": "Login or contributor", "schema": {"type": "string"}},
+          {"name": "year", "in": "query", "required": true, "schema": {"type": "integer"}},
+          {"name": "month", "in": "query", "required": true, "schema": {"type": "integer", "minimum": 1, "maximum": 12}},
+          {"name": "org", "in": "query", "schema": {"type": "string"}},
+          {"name": "snapshot", "in": "query", "description": "Return the frozen snapshot of the month", "schema": {"type": "boolean", "default": false}}
+        ],
+        "responses": {
+          "200": {"description": "User information", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/UserInformation"}}}},
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"description": "Unauthorized"},
+          "403": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/repos/{repo}/pulls": {
+      "get": {
+        "summary": "Page of pull requests of a repository (listpullrequests, reader role)",
+        "operationId": "repoPulls",
+        "parameters": [
+          {"name": "repo", "in": "path", "required": true, "description": "Repository name", "schema": {"type": "string"}},
+          {"name": "org", "in": "query", "schema": {"type": "string"}},
+          {"name": "author", "in": "query", "schema": {"type": "string"}},
+          {"name": "state", "in": "query", "schema": {"type": "string"}},
+          {"name": "label", "in": "query", "schema": {"type": "string"}},
+          {"name": "mergedafter", "in": "query", "schema": {"type": "integer", "format": "int64"}},
+          {"name": "mergedbefore", "in": "query", "schema": {"type": "integer", "format": "int64"}},
+          {"name": "closedafter", "in": "query", "schema": {"type": "integer", "format": "int64"}},
+          {"name": "closedbefore", "in": "query", "schema": {"type": "integer", "format": "int64"}},
+          {"name": "updatedafter", "in": "query", "schema": {"type": "integer", "format": "int64"}},
+          {"name": "updatedbefore", "in": "query", "schema": {"type": "integer", "format": "int64"}},
+          {"name": "sort", "in": "query", "schema": {"type": "string", "enum": ["updated", "created", "merged", "closed", "number"]}},
+          {"name": "order", "in": "query", "schema": {"type": "string", "enum": ["asc", "desc"]}},
+          {"name": "cursor", "in": "query", "schema": {"type": "string"}},
+          {"name": "limit", "in": "query", "schema": {"type": "integer", "default": 100}}
+        ],
+        "responses": {
+          "200": {"description": "Pull requests", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/PullRequests"}}}},
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"description": "Unauthorized"},
+          "403": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/sync": {
+      "post": {
+        "summary": "Sync an organization from GitHub (update, operator role)",
+        "operationId": "sync",
+        "parameters": [
+          {"name": "org", "in": "query", "required": true, "schema": {"type": "string"}}
+        ],
+        "responses": {
+          "204": {"description": "Sync completed"},
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"description": "Unauthorized"},
+          "403": {"$ref": "#/components/responses/Error"},
+          "500": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    }
+  },
+  "components": {
+    "securitySchemes": {
+      "basicAuth": {"type": "http", "scheme": "basic"},
+      "bearerAuth": {"type": "http", "scheme": "bearer", "description": "API key"}
+    },
+    "responses": {
+      "Error": {
+        "description": "Error",
+        "content": {"application/json": {"schema": {
+          "type": "object",
+          "properties": {"error": {"type": "object", "properties": {
+            "code": {"type": "integer"},
+            "message": {"type": "string"}
+          }}}
+        }}}
+      }
+    },
+    "schemas": {
+      "PullRequest": {
+        "type": "object",
+        "properties": {
+          "repo": {"type": "string"},
+          "author": {"type": "string"},
+          "url": {"type": "string"},
+          "number": {"type": "integer"},
+          "title": {"type": "string"},
+          "body": {"type": "string"},
+          "labels": {"type": "array", "items": {"type": "string"}},
+          "baseref": {"type": "string"},
+          "headref": {"type": "string"},
+          "headrepo": {"type": "string"},
+          "fork": {"type": "boolean"},
+          "draft": {"type": "boolean"},
+          "createdat": {"type": "integer", "format": "int64"},
+          "requestedreviewers": {"type": "array", "items": {"type": "string"}},
+          "milestone": {"type": "string"},
+          "additions": {"type": "integer"},
+          "deletions": {"type": "integer"},
+          "date": {"type": "string"},
+          "state": {"type": "string"}
+        }
+      },
+      "PullRequests": {
+        "type": "object",
+        "properties": {
+          "pullrequests": {"type": "array", "items": {"$ref": "#/components/schemas/PullRequest"}},
+          "nextcursor": {"type": "string"}
+        }
+      },
+      "UserInformation": {
+        "type": "object",
+        "properties": {
+          "user": {"type": "string"},
+          "contributor": {"type": "string"},
+          "logins": {"type": "array", "items": {"type": "string"}},
+          "emails": {"type": "array", "items": {"type": "string"}},
+          "organization": {"type": "string"},
+          "prs": {"type": "array", "items": {"$ref": "#/components/schemas/PullRequest"}},
+          "repodetails": {"type": "array", "items": {"type": "object"}},
+          "reviews": {"type": "array", "items": {"type": "object"}},
+          "commits": {"type": "array", "items": {"type": "object"}},
+          "snapshot": {"type": "object", "properties": {
+            "hash": {"type": "string"},
+            "date": {"type": "string"}
+          }}
+        }
+      }
+    }
+  }
+}
+`
@@ -0,0 +1,286 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"runtime/trace"
+	"strconv"
+	"strings"
+
+	"github.com/decred/dcrd/dcrjson/v3"
+	"github.com/decred/github-tracker/jsonrpc/types"
+)
+
+// restPrefix is the path prefix of the REST gateway.
+const restPrefix = "/api/v1/"
+
+// restRoute describes a REST endpoint.  The path segments are matched
+// literally except for "*", which matches any non-empty segment and is passed
+// to cmd.  cmd returns the parsed command of the RPC method the endpoint
+// dispatches to.
+type restRoute struct {
+	httpMethod string
+	path       []string
+	method     string
+	cmd        func(args []string, r *http.Request) (interface{}, error)
+}
+
+// restRoutes are the endpoints of the REST gateway.
+var restRoutes = []restRoute{
+	{http.MethodGet, []string{"users", "*", "stats"}, "userinformation", userStatsCmd},
+	{http.MethodGet, []string{"repos", "*", "pulls"}, "listpullrequests", repoPullsCmd},
+	{http.MethodPost, []string{"sync"}, "update", syncCmd},
+}
+
+// userStatsCmd parses the parameters of GET /api/v1/users/{user}/stats.
+func userStatsCmd(args []string, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+	year, err := queryInt(q, "year", true)
+	if err != nil {
+		return nil, err
+	}
+	month, err := queryInt(q, "month", true)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := queryBool(q, "snapshot")
+	if err != nil {
+		return nil, err
+	}
+	return &types.UserInformationCmd{
+		User:     args[0],
+		Org:      q.Get("org"),
+		Year:     int(year),
+		Month:    int(month),
+		Snapshot: &snapshot,
+	}, nil
+}
+
+// repoPullsCmd parses the parameters of GET /api/v1/repos/{repo}/pulls.
+func repoPullsCmd(args []string, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+	filter := &types.PullRequestFilter{
+		Org:    q.Get("org"),
+		Repo:   args[0],
+		Author: q.Get("author"),
+		State:  q.Get("state"),
+		Label:  q.Get("label"),
+		Sort:   q.Get("sort"),
+		Order:  q.Get("order"),
+	}
+	times := []struct {
+		name string
+		v    *int64
+	}{
+		{"mergedafter", &filter.MergedAfter},
+		{"mergedbefore", &filter.MergedBefore},
+		{"closedafter", &filter.ClosedAfter},
+		{"closedbefore", &filter.ClosedBefore},
+		{"updatedafter", &filter.UpdatedAfter},
+		{"updatedbefore", &filter.UpdatedBefore},
+	}
+	for _, t := range times {
+		v, err := queryInt(q, t.name, false)
+		if err != nil {
+			return nil, err
+		}
+		*t.v = v
+	}
+	cursor := q.Get("cursor")
+	limit := 100
+	if q.Get("limit") != "" {
+		v, err := queryInt(q, "limit", true)
+		if err != nil {
+			return nil, err
+		}
+		limit = int(v)
+	}
+	return &types.ListPullRequestsCmd{
+		Filter: filter,
+		Cursor: &cursor,
+		Limit:  &limit,
+	}, nil
+}
+
+// syncCmd parses the parameters of POST /api/v1/sync.  The organization is
+// passed as the org query or form value.
+func syncCmd(args []string, r *http.Request) (interface{}, error) {
+	org := r.FormValue("org")
+	if org == "" {
+		return nil, rpcErrorf(dcrjson.ErrRPCInvalidParameter,
+			"missing org parameter")
+	}
+	return &types.UpdateCmd{
+		Organization: org,
+	}, nil
+}
+
+// queryInt parses the integer query parameter with the passed name.  Zero is
+// returned for missing optional parameters.
+func queryInt(q url.Values, name string, required bool) (int64, error) {
+	s := q.Get(name)
+	if s == "" {
+		if required {
+			return 0, rpcErrorf(dcrjson.ErrRPCInvalidParameter,
+				"missing %v parameter", name)
+		}
+		return 0, nil
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, rpcErrorf(dcrjson.ErrRPCInvalidParameter,
+			"invalid %v parameter %q", name, s)
+	}
+	return v, nil
+}
+
+// queryBool parses the optional boolean query parameter with the passed name.
+func queryBool(q url.Values, name string) (bool, error) {
+	s := q.Get(name)
+	if s == "" {
+		return false, nil
+	}
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, rpcErrorf(dcrjson.ErrRPCInvalidParameter,
+			"invalid %v parameter %q", name, s)
+	}
+	return v, nil
+}
+
+// matchRESTRoute returns the route matching the passed path segments along
+// with the values of its wildcard segments.  The HTTP methods of the routes
+// matching the path are returned when none matches the HTTP method.
+func matchRESTRoute(httpMethod string, segments []string) (*restRoute, []string, []string) {
+	var allowed []string
+	for i := range restRoutes {
+		route := &restRoutes[i]
+		if len(route.path) != len(segments) {
+			continue
+		}
+		var args []string
+		match := true
+		for j, seg := range route.path {
+			switch {
+			case seg == "*" && segments[j] != "":
+				args = append(args, segments[j])
+			case seg != segments[j]:
+				match = false
+			}
+		}
+		if !match {
+			continue
+		}
+		if route.httpMethod != httpMethod {
+			allowed = append(allowed, route.httpMethod)
+			continue
+		}
+		return route, args, nil
+	}
+	return nil, nil, allowed
+}
+
+// restStatus returns the HTTP status code of an RPC error.
+func restStatus(jsonErr *dcrjson.RPCError) int {
+	switch jsonErr.Code {
+	case ErrRPCForbidden:
+		return http.StatusForbidden
+	case dcrjson.ErrRPCInvalidParameter, dcrjson.ErrRPCInvalidParams.Code:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// restRespond writes the JSON encoded result, or the error wrapped in an
+// object with an error field, to a REST client.
+func restRespond(ctx context.Context, w http.ResponseWriter, result interface{}, jsonErr *dcrjson.RPCError) {
+	status := http.StatusOK
+	if jsonErr != nil {
+		status = restStatus(jsonErr)
+		result = struct {
+			Error *dcrjson.RPCError `json:"error"`
+		}{jsonErr}
+	} else if result == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		log.Errorf("Unable to marshal response to client %s: %v",
+			remoteAddr(ctx), err)
+		http.Error(w, "500 Internal Server Error",
+			http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, err = w.Write(b)
+	if err != nil {
+		log.Warnf("Failed to write response to client %s: %v",
+			remoteAddr(ctx), err)
+	}
+}
+
+// restClient serves a request of a REST client by dispatching it to the
+// handler of the RPC method of the matching route.
+func (s *Server) restClient(w http.ResponseWriter, r *http.Request) {
+	ctx := withRemoteAddr(r.Context(), r.RemoteAddr)
+
+	path := strings.TrimPrefix(r.URL.Path, restPrefix)
+	if path == "openapi.json" && r.Method == http.MethodGet {
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write([]byte(openAPIDocument))
+		if err != nil {
+			log.Warnf("Failed to write response to client %s: %v",
+				r.RemoteAddr, err)
+		}
+		return
+	}
+
+	id, err := s.checkAuthHeader(r)
+	if err != nil {
+		log.Warnf("Failed authentication attempt from client %s",
+			r.RemoteAddr)
+		jsonAuthFail(w)
+		return
+	}
+	ctx = withIdentity(ctx, id)
+
+	route, args, allowed := matchRESTRoute(r.Method, strings.Split(path, "/"))
+	if route == nil {
+		if len(allowed) != 0 {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			http.Error(w, "405 Method Not Allowed",
+				http.StatusMethodNotAllowed)
+			return
+		}
+		http.NotFound(w, r)
+		return
+	}
+
+	ctx, task := trace.NewTask(ctx, route.method)
+	defer task.End()
+	log.Infof("REST %v %v invoked by %v", r.Method, r.URL.Path, r.RemoteAddr)
+
+	cmd, err := route.cmd(args, r)
+	if err != nil {
+		restRespond(ctx, w, nil, convertError(err))
+		return
+	}
+	countCall(route.method)
+	handlerData := handlers[route.method]
+	if jsonErr := handlerData.checkRole(ctx, route.method); jsonErr != nil {
+		restRespond(ctx, w, nil, jsonErr)
+		return
+	}
+	result, jsonErr := handlerData.call(s, ctx, cmd)
+	restRespond(ctx, w, result, jsonErr)
+}
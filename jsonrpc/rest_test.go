@@ -0,0 +1,90 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package jsonrpc
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestMatchRESTRoute(t *testing.T) {
+	tests := []struct {
+		name       string
+		httpMethod string
+		segments   []string
+		method     string
+		args       []string
+		allowed    []string
+	}{
+		{
+			name:       "user stats",
+			httpMethod: http.MethodGet,
+			segments:   []string{"users", "alice", "stats"},
+			method:     "userinformation",
+			args:       []string{"alice"},
+		},
+		{
+			name:       "repo pulls",
+			httpMethod: http.MethodGet,
+			segments:   []string{"repos", "dcrd", "pulls"},
+			method:     "listpullrequests",
+			args:       []string{"dcrd"},
+		},
+		{
+			name:       "sync",
+			httpMethod: http.MethodPost,
+			segments:   []string{"sync"},
+			method:     "update",
+		},
+		{
+			name:       "sync method",
+			httpMethod: http.MethodGet,
+			segments:   []string{"sync"},
+			allowed:    []string{http.MethodPost},
+		},
+		{
+			name:       "stats method",
+			httpMethod: http.MethodDelete,
+			segments:   []string{"users", "alice", "stats"},
+			allowed:    []string{http.MethodGet},
+		},
+		{
+			name:       "empty wildcard",
+			httpMethod: http.MethodGet,
+			segments:   []string{"users", "", "stats"},
+		},
+		{
+			name:       "unknown",
+			httpMethod: http.MethodGet,
+			segments:   []string{"users", "alice"},
+		},
+		{
+			name:       "trailing segment",
+			httpMethod: http.MethodGet,
+			segments:   []string{"users", "alice", "stats", ""},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			route, args, allowed := matchRESTRoute(test.httpMethod,
+				test.segments)
+			var method string
+			if route != nil {
+				method = route.method
+			}
+			if method != test.method {
+				t.Fatalf("got method %q, want %q", method, test.method)
+			}
+			if !reflect.DeepEqual(args, test.args) {
+				t.Fatalf("got args %q, want %q", args, test.args)
+			}
+			if !reflect.DeepEqual(allowed, test.allowed) {
+				t.Fatalf("got allowed %q, want %q", allowed,
+					test.allowed)
+			}
+		})
+	}
+}
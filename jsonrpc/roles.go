@@ -0,0 +1,129 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package jsonrpc
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/decred/github-tracker/database"
+)
+
+// Role is the permission level of an RPC identity.  Every role is granted the
+// permissions of the roles below it.
+type Role int
+
+const (
+	// RoleReader may query stats, pull requests and aliases.
+	RoleReader Role = iota
+
+	// RoleOperator may additionally trigger syncs and manage aliases and
+	// snapshots.
+	RoleOperator
+
+	// RoleAdmin may additionally erase user data.
+	RoleAdmin
+)
+
+// roleNames maps each role to the name used in configuration.
+var roleNames = map[Role]string{
+	RoleReader:   "reader",
+	RoleOperator: "operator",
+	RoleAdmin:    "admin",
+}
+
+// String returns the configuration name of the role.
+func (r Role) String() string {
+	if name, ok := roleNames[r]; ok {
+		return name
+	}
+	return fmt.Sprintf("Role(%d)", int(r))
+}
+
+// ParseRole returns the role with the passed configuration name.
+func ParseRole(name string) (Role, error) {
+	for role, roleName := range roleNames {
+		if roleName == name {
+			return role, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown role %q", name)
+}
+
+// scopeRoles maps each API key scope to the role it grants.
+var scopeRoles = map[string]Role{
+	database.APIKeyScopeRead:  RoleReader,
+	database.APIKeyScopeSync:  RoleOperator,
+	database.APIKeyScopeAdmin: RoleAdmin,
+}
+
+// User is a set of RPC credentials along with the role they are granted.
+type User struct {
+	Username string
+	Password string
+	Role     Role
+}
+
+// identity describes an authenticated RPC caller.
+type identity struct {
+	name string
+	role Role
+}
+
+// ReadUsers parses a users file.  Every line holds the username, password and
+// role of a user separated by whitespace.  Empty lines and lines starting with
+// # are ignored.
+func ReadUsers(r io.Reader) ([]User, error) {
+	var users []User
+	seen := make(map[string]struct{})
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: expected username, "+
+				"password and role", line)
+		}
+		role, err := ParseRole(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if _, ok := seen[fields[0]]; ok {
+			return nil, fmt.Errorf("line %d: duplicate user %q", line,
+				fields[0])
+		}
+		seen[fields[0]] = struct{}{}
+		users = append(users, User{
+			Username: fields[0],
+			Password: fields[1],
+			Role:     role,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// apiKeyIdentity returns the identity of the caller presenting the passed API
+// key, which is granted the role of the scope of the key.
+func (a *Authenticator) apiKeyIdentity(ctx context.Context, key string) (*identity, error) {
+	apiKey, err := a.server.AuthenticateAPIKey(ctx, strings.TrimSpace(key))
+	if err != nil {
+		return nil, err
+	}
+	role, ok := scopeRoles[apiKey.Scope]
+	if !ok {
+		return nil, fmt.Errorf("api key %v has unknown scope %q",
+			apiKey.ID, apiKey.Scope)
+	}
+	return &identity{name: "apikey:" + apiKey.ID, role: role}, nil
+}
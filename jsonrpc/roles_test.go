@@ -0,0 +1,173 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package jsonrpc
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRole(t *testing.T) {
+	tests := []struct {
+		name    string
+		role    Role
+		wantErr bool
+	}{
+		{name: "reader", role: RoleReader},
+		{name: "operator", role: RoleOperator},
+		{name: "admin", role: RoleAdmin},
+		{name: "Admin", wantErr: true},
+		{name: "", wantErr: true},
+	}
+	for _, test := range tests {
+		role, err := ParseRole(test.name)
+		if (err != nil) != test.wantErr {
+			t.Errorf("ParseRole(%q): got error %v, want error %v",
+				test.name, err, test.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if role != test.role {
+			t.Errorf("ParseRole(%q): got %v, want %v", test.name, role,
+				test.role)
+		}
+		if role.String() != test.name {
+			t.Errorf("%v.String(): got %q, want %q", role, role.String(),
+				test.name)
+		}
+	}
+}
+
+func TestReadUsers(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		users   []User
+		wantErr bool
+	}{
+		{
+			name: "users",
+			file: "# username password role\n" +
+				"\n" +
+				"alice  secret1 admin\n" +
+				"\tbob secret2\toperator  \n" +
+				"   # indented comment\n" +
+				"carol secret3 reader",
+			users: []User{
+				{Username: "alice", Password: "secret1", Role: RoleAdmin},
+				{Username: "bob", Password: "secret2", Role: RoleOperator},
+				{Username: "carol", Password: "secret3", Role: RoleReader},
+			},
+		},
+		{
+			name: "empty",
+			file: "# no users\n",
+		},
+		{
+			name:    "missing role",
+			file:    "alice secret1\n",
+			wantErr: true,
+		},
+		{
+			name:    "extra field",
+			file:    "alice secret1 admin extra\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown role",
+			file:    "alice secret1 root\n",
+			wantErr: true,
+		},
+		{
+			name:    "duplicate",
+			file:    "alice secret1 admin\nalice secret2 reader\n",
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			users, err := ReadUsers(strings.NewReader(test.file))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error %v", err,
+					test.wantErr)
+			}
+			if !reflect.DeepEqual(users, test.users) {
+				t.Fatalf("got %+v, want %+v", users, test.users)
+			}
+		})
+	}
+}
+
+func TestCheckRole(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		id     *identity
+		allow  bool
+	}{
+		{
+			name:   "anonymous",
+			method: "userinformation",
+		},
+		{
+			name:   "reader read",
+			method: "userinformation",
+			id:     &identity{name: "carol", role: RoleReader},
+			allow:  true,
+		},
+		{
+			name:   "reader sync",
+			method: "update",
+			id:     &identity{name: "carol", role: RoleReader},
+		},
+		{
+			name:   "operator sync",
+			method: "update",
+			id:     &identity{name: "bob", role: RoleOperator},
+			allow:  true,
+		},
+		{
+			name:   "operator purge",
+			method: "purgeuser",
+			id:     &identity{name: "bob", role: RoleOperator},
+		},
+		{
+			name:   "admin purge",
+			method: "purgeuser",
+			id:     &identity{name: "alice", role: RoleAdmin},
+			allow:  true,
+		},
+		{
+			name:   "admin read",
+			method: "listpullrequests",
+			id:     &identity{name: "alice", role: RoleAdmin},
+			allow:  true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			if test.id != nil {
+				ctx = withIdentity(ctx, test.id)
+			}
+			h := handlers[test.method]
+			jsonErr := h.checkRole(ctx, test.method)
+			if test.allow {
+				if jsonErr != nil {
+					t.Fatalf("unexpected error %v", jsonErr)
+				}
+				return
+			}
+			if jsonErr == nil || jsonErr.Code != ErrRPCForbidden {
+				t.Fatalf("got %v, want code %v", jsonErr,
+					ErrRPCForbidden)
+			}
+		})
+	}
+}
@@ -6,9 +6,11 @@
 package jsonrpc
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"crypto/subtle"
+	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
 	"io"
@@ -16,6 +18,7 @@ import (
 	"net"
 	"net/http"
 	"runtime/trace"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -31,21 +34,28 @@ import (
 type websocketClient struct {
 	conn          *websocket.Conn
 	authenticated bool
+	identity      *identity // Set once authenticated
 	allRequests   chan []byte
 	responses     chan []byte
 	cancel        func()
 	quit          chan struct{} // closed on disconnect
 	wg            sync.WaitGroup
+
+	ntfnMtx      sync.Mutex
+	ntfns        map[types.Method]bool // Subscribed notification methods
+	subscription *server.Subscription
 }
 
-func newWebsocketClient(c *websocket.Conn, cancel func(), authenticated bool) *websocketClient {
+func newWebsocketClient(c *websocket.Conn, cancel func(), id *identity) *websocketClient {
 	return &websocketClient{
 		conn:          c,
-		authenticated: authenticated,
+		authenticated: id != nil,
+		identity:      id,
 		allRequests:   make(chan []byte),
 		responses:     make(chan []byte),
 		cancel:        cancel,
 		quit:          make(chan struct{}),
+		ntfns:         make(map[types.Method]bool),
 	}
 }
 
@@ -63,9 +73,10 @@ func (c *websocketClient) send(b []byte) error {
 type Server struct {
 	httpServer http.Server
 	listeners  []net.Listener
-	authsha    [sha256.Size]byte
+	auth       *Authenticator
 	upgrader   websocket.Upgrader
-	server     server.Server
+	server     *server.Server
+	reader     *server.Reader
 
 	cfg Options
 
@@ -76,9 +87,13 @@ type Server struct {
 	requestShutdownChan chan struct{}
 }
 
+// handler describes an RPC method.  Methods that only read from the database
+// set read instead of fn, so that they are only given the read only part of
+// the server.
 type handler struct {
-	fn     func(*Server, context.Context, interface{}) (interface{}, error)
-	noHelp bool
+	fn   func(*Server, context.Context, interface{}) (interface{}, error)
+	read func(*server.Reader, context.Context, interface{}) (interface{}, error)
+	role Role // Role the caller must be granted
 }
 
 // jsonAuthFail sends a message back to the client if the http auth is rejected.
@@ -89,7 +104,7 @@ func jsonAuthFail(w http.ResponseWriter) {
 
 // NewServer creates a new server for serving JSON-RPC client connections,
 // both HTTP POST and websocket.
-func NewServer(opts *Options, listeners []net.Listener, s server.Server) *Server {
+func NewServer(opts *Options, listeners []net.Listener, s *server.Server) *Server {
 	serveMux := http.NewServeMux()
 	const rpcAuthTimeoutSeconds = 10
 	server := &Server{
@@ -102,9 +117,7 @@ func NewServer(opts *Options, listeners []net.Listener, s server.Server) *Server
 		},
 		cfg:       *opts,
 		listeners: listeners,
-		// A hash of the HTTP basic auth string is used for a constant
-		// time comparison.
-		authsha: sha256.Sum256(httpBasicAuth(opts.Username, opts.Password)),
+		auth:      NewAuthenticator(opts, s),
 		upgrader: websocket.Upgrader{
 			// Allow all origins.
 			CheckOrigin: func(r *http.Request) bool { return true },
@@ -112,6 +125,7 @@ func NewServer(opts *Options, listeners []net.Listener, s server.Server) *Server
 		quit:                make(chan struct{}),
 		requestShutdownChan: make(chan struct{}, 1),
 		server:              s,
+		reader:              s.Reader,
 	}
 
 	serveMux.Handle("/", throttledFn(opts.MaxPOSTClients,
@@ -120,7 +134,8 @@ func NewServer(opts *Options, listeners []net.Listener, s server.Server) *Server
 			w.Header().Set("Content-Type", "application/json")
 			r.Close = true
 
-			if err := server.checkAuthHeader(r); err != nil {
+			id, err := server.checkAuthHeader(r)
+			if err != nil {
 				log.Warnf("Failed authentication attempt from client %s",
 					r.RemoteAddr)
 				jsonAuthFail(w)
@@ -128,7 +143,44 @@ func NewServer(opts *Options, listeners []net.Listener, s server.Server) *Server
 			}
 			server.wg.Add(1)
 			defer server.wg.Done()
-			server.postClientRPC(w, r)
+			server.postClientRPC(w, r.WithContext(withIdentity(r.Context(), id)))
+		}))
+
+	serveMux.Handle(restPrefix, throttledFn(opts.MaxPOSTClients,
+		func(w http.ResponseWriter, r *http.Request) {
+			server.wg.Add(1)
+			defer server.wg.Done()
+			server.restClient(w, r)
+		}))
+
+	serveMux.Handle("/ws", throttledFn(opts.MaxWebsocketClients,
+		func(w http.ResponseWriter, r *http.Request) {
+			id, err := server.checkAuthHeader(r)
+			switch err {
+			case nil:
+			case errNoAuth:
+				// Clients may authenticate with the
+				// authenticate method instead.
+			default:
+				// If auth was supplied but incorrect, rather
+				// than simply being missing, immediately
+				// terminate the connection.
+				log.Warnf("Disconnecting improperly authorized "+
+					"websocket client %s", r.RemoteAddr)
+				jsonAuthFail(w)
+				return
+			}
+
+			conn, err := server.upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				log.Warnf("Cannot websocket upgrade client %s: %v",
+					r.RemoteAddr, err)
+				return
+			}
+			ctx := withRemoteAddr(r.Context(), r.RemoteAddr)
+			ctx, cancel := context.WithCancel(ctx)
+			wsc := newWebsocketClient(conn, cancel, id)
+			server.websocketClientRPC(ctx, wsc)
 		}))
 
 	for _, lis := range listeners {
@@ -214,22 +266,114 @@ func (s *Server) handlerClosure(ctx context.Context, request *dcrjson.Request) l
 // due to a missing Authorization HTTP header.
 var errNoAuth = errors.E("missing Authorization header")
 
-// checkAuthHeader checks the HTTP Basic authentication supplied by a client
-// in the HTTP request r.
+// authUser is an RPC user along with the hash of its HTTP Basic authentication
+// string, which is used for a constant time comparison.
+type authUser struct {
+	authsha [sha256.Size]byte
+	id      identity
+}
+
+// Authenticator identifies RPC callers by their HTTP Basic credentials, API
+// keys or TLS client certificates.  It is shared by the JSON-RPC and gRPC
+// servers.
+type Authenticator struct {
+	users       []authUser
+	clientCerts []ClientCert
+	server      *server.Server
+}
+
+// NewAuthenticator returns an authenticator for the users and client
+// certificates of the passed options and the API keys of the passed server.
+func NewAuthenticator(opts *Options, s *server.Server) *Authenticator {
+	return &Authenticator{
+		users:       makeAuthUsers(opts),
+		clientCerts: opts.ClientCerts,
+		server:      s,
+	}
+}
+
+// makeAuthUsers returns the users allowed to authenticate with the server.
+// The username and password of the options are granted the admin role.
+func makeAuthUsers(opts *Options) []authUser {
+	users := make([]authUser, 0, len(opts.Users)+1)
+	if opts.Username != "" && opts.Password != "" {
+		users = append(users, authUser{
+			authsha: sha256.Sum256(httpBasicAuth(opts.Username,
+				opts.Password)),
+			id: identity{name: opts.Username, role: RoleAdmin},
+		})
+	}
+	for _, user := range opts.Users {
+		users = append(users, authUser{
+			authsha: sha256.Sum256(httpBasicAuth(user.Username,
+				user.Password)),
+			id: identity{name: user.Username, role: user.Role},
+		})
+	}
+	return users
+}
+
+// basicAuthIdentity returns the identity of the user the passed HTTP Basic
+// authentication string belongs to, or nil when it matches no user.
 //
-// The authentication comparison is time constant.
-func (s *Server) checkAuthHeader(r *http.Request) error {
-	authhdr := r.Header["Authorization"]
-	if len(authhdr) == 0 {
-		return errNoAuth
+// The authentication comparison is time constant and every user is compared.
+func (a *Authenticator) basicAuthIdentity(auth []byte) *identity {
+	authsha := sha256.Sum256(auth)
+	var id *identity
+	for i := range a.users {
+		user := &a.users[i]
+		cmp := subtle.ConstantTimeCompare(authsha[:], user.authsha[:])
+		if cmp == 1 {
+			id = &user.id
+		}
+	}
+	return id
+}
+
+// checkAuthHeader checks the HTTP Basic or Bearer API key authentication
+// supplied by a client in the HTTP request r and returns the identity of the
+// client.  Clients that send no Authorization header are identified by their
+// TLS client certificate when it is mapped to a role.
+func (s *Server) checkAuthHeader(r *http.Request) (*identity, error) {
+	var auth string
+	if authhdr := r.Header["Authorization"]; len(authhdr) != 0 {
+		auth = authhdr[0]
+	}
+	return s.auth.checkAuth(r.Context(), auth, r.TLS)
+}
+
+// checkAuth returns the identity of the client presenting the passed
+// Authorization header value, or the identity of its TLS client certificate
+// when the value is empty.
+func (a *Authenticator) checkAuth(ctx context.Context, auth string, state *tls.ConnectionState) (*identity, error) {
+	if auth == "" {
+		if id := a.clientCertIdentity(state); id != nil {
+			return id, nil
+		}
+		return nil, errNoAuth
+	}
+
+	const bearer = "Bearer "
+	if len(auth) > len(bearer) && strings.EqualFold(auth[:len(bearer)], bearer) {
+		return a.apiKeyIdentity(ctx, auth[len(bearer):])
 	}
 
-	authsha := sha256.Sum256([]byte(authhdr[0]))
-	cmp := subtle.ConstantTimeCompare(authsha[:], s.authsha[:])
-	if cmp != 1 {
-		return errors.New("invalid Authorization header")
+	id := a.basicAuthIdentity([]byte(auth))
+	if id == nil {
+		return nil, errors.New("invalid Authorization header")
+	}
+	return id, nil
+}
+
+// Authenticate identifies the caller presenting the passed Authorization
+// header value and TLS connection state.  The name and role of the caller are
+// returned.
+func (a *Authenticator) Authenticate(ctx context.Context, auth string, state *tls.ConnectionState) (string, Role, error) {
+	id, err := a.checkAuth(ctx, auth, state)
+	if err != nil {
+		return "", 0, err
 	}
-	return nil
+	return id.name, id.role, nil
 }
 
 // throttledFn wraps an http.HandlerFunc with throttling of concurrent active
@@ -249,6 +393,7 @@ func throttled(threshold int64, h http.Handler) http.Handler {
 
 		if current-1 >= threshold {
 			log.Warnf("Reached threshold of %d concurrent active clients", threshold)
+			throttledTotal.Inc()
 			http.Error(w, "429 Too Many Requests", http.StatusTooManyRequests)
 			return
 		}
@@ -268,23 +413,22 @@ func idPointer(id interface{}) (p *interface{}) {
 	return
 }
 
-// invalidAuth checks whether a websocket request is a valid (parsable)
-// authenticate request and checks the supplied username and passphrase
-// against the server auth.
-func (s *Server) invalidAuth(req *dcrjson.Request) bool {
-	cmd, err := dcrjson.ParseParams(types.Method(req.Method), req.Params)
+// authenticateIdentity checks whether a websocket request is a valid
+// (parsable) authenticate request and returns the identity of the user the
+// supplied username and passphrase belong to.  Nil is returned when the
+// request or credentials are invalid.
+func (s *Server) authenticateIdentity(req *dcrjson.Request) *identity {
+	cmd, err := dcrjson.ParseParams(dcrdtypes.Method(req.Method), req.Params)
 	if err != nil {
-		return false
+		return nil
 	}
 	authCmd, ok := cmd.(*dcrdtypes.AuthenticateCmd)
 	if !ok {
-		return false
+		return nil
 	}
 	// Check credentials.
-	login := authCmd.Username + ":" + authCmd.Passphrase
-	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
-	authSha := sha256.Sum256([]byte(auth))
-	return subtle.ConstantTimeCompare(authSha[:], s.authsha[:]) != 1
+	return s.auth.basicAuthIdentity(httpBasicAuth(authCmd.Username,
+		authCmd.Passphrase))
 }
 
 func (s *Server) websocketClientRead(ctx context.Context, wsc *websocketClient) {
@@ -299,14 +443,264 @@ func (s *Server) websocketClientRead(ctx context.Context, wsc *websocketClient)
 			wsc.cancel()
 			break
 		}
-		wsc.allRequests <- request
+		select {
+		case wsc.allRequests <- request:
+		case <-wsc.quit:
+			return
+		}
+	}
+}
+
+// websocketClientRPC serves the requests of a websocket client until it
+// disconnects or the server is stopped.
+func (s *Server) websocketClientRPC(ctx context.Context, wsc *websocketClient) {
+	log.Infof("New websocket client %s", remoteAddr(ctx))
+
+	// Clear the read deadline set before the websocket hijacked
+	// the connection.
+	if err := wsc.conn.SetReadDeadline(time.Time{}); err != nil {
+		log.Warnf("Cannot remove read deadline: %v", err)
 	}
+
+	// websocketClientRead is intentionally not run with the waitgroup
+	// so it is ignored during shutdown.  This is to prevent a hang during
+	// shutdown where the goroutine is blocked on a read of the
+	// websocket connection if the client is still connected.
+	go s.websocketClientRead(ctx, wsc)
+
+	s.wg.Add(2)
+	go s.websocketClientRespond(ctx, wsc)
+	go s.websocketClientSend(ctx, wsc)
+
+	<-wsc.quit
+	wsc.conn.Close()
+}
+
+// websocketClientRespond handles the requests of a websocket client.  Requests
+// other than authenticate and the notification subscriptions are handled
+// concurrently.
+func (s *Server) websocketClientRespond(ctx context.Context, wsc *websocketClient) {
+	// A for-select with a read of the quit channel is used instead of a
+	// for-range to provide clean shutdown.  This is necessary due to
+	// websocketClientRead (which sends to the allRequests chan) not
+	// closing allRequests during shutdown if the remote websocket client
+	// is still connected.
+out:
+	for {
+		select {
+		case reqBytes, ok := <-wsc.allRequests:
+			if !ok {
+				// client disconnected
+				break out
+			}
+
+			var req dcrjson.Request
+			err := json.Unmarshal(reqBytes, &req)
+			if err != nil {
+				if !wsc.authenticated {
+					// Disconnect immediately.
+					break out
+				}
+				resp := makeResponse(req.ID, nil,
+					dcrjson.ErrRPCInvalidRequest)
+				err = s.websocketSendResponse(wsc, &resp)
+				if err != nil {
+					break out
+				}
+				continue
+			}
+
+			if req.Method == "authenticate" {
+				var id *identity
+				if !wsc.authenticated {
+					id = s.authenticateIdentity(&req)
+				}
+				if id == nil {
+					// Disconnect immediately.
+					log.Warnf("Failed websocket authentication "+
+						"attempt from client %s", remoteAddr(ctx))
+					break out
+				}
+				wsc.authenticated = true
+				wsc.identity = id
+				resp := makeResponse(req.ID, nil, nil)
+				err = s.websocketSendResponse(wsc, &resp)
+				if err != nil {
+					break out
+				}
+				continue
+			}
+
+			if !wsc.authenticated {
+				// Disconnect immediately.
+				break out
+			}
+
+			switch types.Method(req.Method) {
+			case "notifysyncprogress":
+				s.websocketNotify(ctx, wsc, types.SyncProgressNtfnMethod)
+				resp := makeResponse(req.ID, nil, nil)
+				err = s.websocketSendResponse(wsc, &resp)
+				if err != nil {
+					break out
+				}
+
+			case "notifynewmerges":
+				s.websocketNotify(ctx, wsc, types.NewMergesNtfnMethod)
+				resp := makeResponse(req.ID, nil, nil)
+				err = s.websocketSendResponse(wsc, &resp)
+				if err != nil {
+					break out
+				}
+
+			default:
+				req := req // Copy for the closure
+				ctx := withIdentity(ctx, wsc.identity)
+				ctx, task := trace.NewTask(ctx, req.Method)
+				f := s.handlerClosure(ctx, &req)
+				wsc.wg.Add(1)
+				go func() {
+					defer wsc.wg.Done()
+					defer task.End()
+					resp, jsonErr := f()
+					if req.Method == "stop" && jsonErr == nil {
+						defer s.requestProcessShutdown()
+					}
+					if isNotification(&req) {
+						// Notifications are not replied to.
+						return
+					}
+					mresp, err := dcrjson.MarshalResponse(req.Jsonrpc,
+						req.ID, resp, jsonErr)
+					if err != nil {
+						log.Errorf("Unable to marshal response to "+
+							"client %s: %v", remoteAddr(ctx), err)
+						return
+					}
+					_ = wsc.send(mresp)
+				}()
+			}
+
+		case <-s.quit:
+			break out
+		}
+	}
+
+	// Stop the handlers and notifications of the client and allow it to
+	// disconnect once they are done.
+	wsc.cancel()
+	wsc.wg.Wait()
+	close(wsc.responses)
+	s.wg.Done()
+}
+
+// websocketSendResponse marshals and queues a response for a websocket client.
+func (s *Server) websocketSendResponse(wsc *websocketClient, resp *dcrjson.Response) error {
+	mresp, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	return wsc.send(mresp)
+}
+
+// websocketClientSend writes the queued responses and notifications to a
+// websocket client.
+func (s *Server) websocketClientSend(ctx context.Context, wsc *websocketClient) {
+	const deadline time.Duration = 2 * time.Second
+out:
+	for {
+		select {
+		case response, ok := <-wsc.responses:
+			if !ok {
+				// client disconnected
+				break out
+			}
+			err := wsc.conn.SetWriteDeadline(time.Now().Add(deadline))
+			if err != nil {
+				log.Warnf("Cannot set write deadline on client %s: %v",
+					remoteAddr(ctx), err)
+			}
+			err = wsc.conn.WriteMessage(websocket.TextMessage, response)
+			if err != nil {
+				log.Warnf("Failed websocket send to client %s: %v",
+					remoteAddr(ctx), err)
+				break out
+			}
+
+		case <-s.quit:
+			break out
+		}
+	}
+	close(wsc.quit)
+	log.Infof("Disconnected websocket client %s", remoteAddr(ctx))
+	s.wg.Done()
+}
+
+// websocketNotify subscribes a websocket client to the notifications of the
+// passed method.  A single subscription to the server notifications is shared
+// by all notification methods of the client and is forwarded until the client
+// disconnects.
+func (s *Server) websocketNotify(ctx context.Context, wsc *websocketClient, method types.Method) {
+	wsc.ntfnMtx.Lock()
+	defer wsc.ntfnMtx.Unlock()
+
+	wsc.ntfns[method] = true
+	if wsc.subscription != nil {
+		return
+	}
+	sub := s.server.Subscribe()
+	wsc.subscription = sub
+
+	wsc.wg.Add(1)
+	go func() {
+		defer wsc.wg.Done()
+		defer sub.Close()
+
+		for {
+			select {
+			case ntfn := <-sub.C:
+				var method types.Method
+				switch ntfn.(type) {
+				case *types.SyncProgressNtfn:
+					method = types.SyncProgressNtfnMethod
+				case *types.NewMergesNtfn:
+					method = types.NewMergesNtfnMethod
+				default:
+					continue
+				}
+				wsc.ntfnMtx.Lock()
+				subscribed := wsc.ntfns[method]
+				wsc.ntfnMtx.Unlock()
+				if !subscribed {
+					continue
+				}
+
+				mntfn, err := dcrjson.MarshalCmd("1.0", nil, ntfn)
+				if err != nil {
+					log.Errorf("Unable to marshal %v notification: %v",
+						method, err)
+					continue
+				}
+				err = wsc.send(mntfn)
+				if err != nil {
+					return
+				}
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
 }
 
 // maxRequestSize specifies the maximum number of bytes in the request body
 // that may be read from a client.  This is currently limited to 4MB.
 const maxRequestSize = 1024 * 1024 * 4
 
+// maxBatchConcurrency is the maximum number of requests of a JSON-RPC batch
+// that are executed at once.
+const maxBatchConcurrency = 8
+
 // postClientRPC processes and replies to a JSON-RPC client request.
 func (s *Server) postClientRPC(w http.ResponseWriter, r *http.Request) {
 	ctx := withRemoteAddr(r.Context(), r.RemoteAddr)
@@ -321,6 +715,13 @@ func (s *Server) postClientRPC(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// JSON-RPC 2.0 batches are arrays of requests.
+	trimmed := bytes.TrimLeft(rpcRequest, " \t\r\n")
+	if len(trimmed) != 0 && trimmed[0] == '[' {
+		s.postClientBatch(ctx, w, r, trimmed)
+		return
+	}
+
 	// First check whether wallet has a handler for this request's method.
 	// If unfound, the request is sent to the chain server for further
 	// processing.  While checking the methods, disallow authenticate
@@ -344,17 +745,26 @@ func (s *Server) postClientRPC(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, task := trace.NewTask(ctx, req.Method)
-	defer task.End()
-
 	// Create the response and error from the request.  Two special cases
 	// are handled for the authenticate and stop request methods.
 	var res interface{}
 	var jsonErr *dcrjson.RPCError
 	var stop bool
 	switch req.Method {
+	case "stop":
+		res, jsonErr = s.postClientRequest(ctx, &req)
+		stop = jsonErr == nil
 	default:
-		res, jsonErr = s.handlerClosure(ctx, &req)()
+		res, jsonErr = s.postClientRequest(ctx, &req)
+	}
+
+	// Notifications are executed without being replied to.
+	if isNotification(&req) {
+		w.WriteHeader(http.StatusNoContent)
+		if stop {
+			s.requestProcessShutdown()
+		}
+		return
 	}
 
 	// Marshal and send.
@@ -376,8 +786,127 @@ func (s *Server) postClientRPC(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// postClientRequest executes a single request of an HTTP POST client.
+func (s *Server) postClientRequest(ctx context.Context, req *dcrjson.Request) (interface{}, *dcrjson.RPCError) {
+	ctx, task := trace.NewTask(ctx, req.Method)
+	defer task.End()
+
+	return s.handlerClosure(ctx, req)()
+}
+
+// isNotification returns whether the passed request is a JSON-RPC 2.0
+// notification, which is not replied to.  JSON-RPC 1.0 requests are always
+// replied to, even without an id.
+func isNotification(req *dcrjson.Request) bool {
+	return req.Jsonrpc == "2.0" && req.ID == nil
+}
+
+// postClientBatch processes and replies to a JSON-RPC 2.0 batch of requests.
+// At most maxBatchConcurrency requests are executed at once.  The responses
+// are written in the order of the requests, leaving out notifications, and
+// nothing is written when the batch only holds notifications.
+func (s *Server) postClientBatch(ctx context.Context, w http.ResponseWriter, r *http.Request, batch []byte) {
+	var rawReqs []json.RawMessage
+	err := json.Unmarshal(batch, &rawReqs)
+	if err != nil || len(rawReqs) == 0 {
+		resp, err := dcrjson.MarshalResponse("2.0", nil, nil,
+			dcrjson.ErrRPCInvalidRequest)
+		if err != nil {
+			log.Errorf("Unable to marshal response to client %s: %v",
+				r.RemoteAddr, err)
+			http.Error(w, "500 Internal Server Error",
+				http.StatusInternalServerError)
+			return
+		}
+		_, err = w.Write(resp)
+		if err != nil {
+			log.Warnf("Cannot write invalid request request to "+
+				"client %s: %v", r.RemoteAddr, err)
+		}
+		return
+	}
+
+	responses := make([]json.RawMessage, len(rawReqs))
+	sem := make(chan struct{}, maxBatchConcurrency)
+	var wg sync.WaitGroup
+	var stop int32
+	for i := range rawReqs {
+		req := new(dcrjson.Request)
+		err := json.Unmarshal(rawReqs[i], req)
+		if err != nil {
+			responses[i], err = dcrjson.MarshalResponse("2.0", nil, nil,
+				dcrjson.ErrRPCInvalidRequest)
+			if err != nil {
+				log.Errorf("Unable to marshal response to "+
+					"client %s: %v", r.RemoteAddr, err)
+			}
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			res, jsonErr := s.postClientRequest(ctx, req)
+			if req.Method == "stop" && jsonErr == nil {
+				atomic.StoreInt32(&stop, 1)
+			}
+			if isNotification(req) {
+				return
+			}
+			resp, err := dcrjson.MarshalResponse(req.Jsonrpc, req.ID,
+				res, jsonErr)
+			if err != nil {
+				log.Errorf("Unable to marshal response to "+
+					"client %s: %v", r.RemoteAddr, err)
+				resp, _ = dcrjson.MarshalResponse(req.Jsonrpc,
+					req.ID, nil, dcrjson.ErrRPCInternal)
+			}
+			responses[i] = resp
+		}(i)
+	}
+	wg.Wait()
+
+	replies := responses[:0]
+	for _, resp := range responses {
+		if resp != nil {
+			replies = append(replies, resp)
+		}
+	}
+	// Shutdown is requested once the replies are written.
+	if atomic.LoadInt32(&stop) != 0 {
+		defer s.requestProcessShutdown()
+	}
+
+	if len(replies) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	mresp, err := json.Marshal(replies)
+	if err != nil {
+		log.Errorf("Unable to marshal batch response to client %s: %v",
+			r.RemoteAddr, err)
+		http.Error(w, "500 Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	_, err = w.Write(mresp)
+	if err != nil {
+		log.Warnf("Failed to write response to client %s: %v",
+			r.RemoteAddr, err)
+	}
+}
+
+// requestProcessShutdown requests a process shutdown without blocking when a
+// shutdown was already requested.
 func (s *Server) requestProcessShutdown() {
-	s.requestShutdownChan <- struct{}{}
+	select {
+	case s.requestShutdownChan <- struct{}{}:
+	default:
+	}
 }
 
 // RequestProcessShutdown returns a channel that is sent to when an authorized
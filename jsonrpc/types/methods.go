@@ -24,12 +24,147 @@ type UpdateCmd struct {
 // UserInformationCmd describes the command and parameters for performing the
 // userinformation method.
 type UserInformationCmd struct {
+	User     string `json:"user"`
+	Org      string `json:"org"`
+	Year     int    `json:"year"`
+	Month    int    `json:"month"`
+	Snapshot *bool  `json:"snapshot" jsonrpcdefault:"false"` // Return the frozen snapshot instead of live data
+}
+
+// PullRequestFilter describes the filters and order applied by the
+// listpullrequests method.  Empty fields are ignored and time ranges are
+// inclusive UNIX timestamps.  Sort is one of updated, created, merged, closed
+// or number and Order is either asc or desc.
+type PullRequestFilter struct {
+	Org           string `json:"org,omitempty"`
+	Repo          string `json:"repo,omitempty"`
+	Author        string `json:"author,omitempty"`
+	State         string `json:"state,omitempty"`
+	Label         string `json:"label,omitempty"`
+	MergedAfter   int64  `json:"mergedafter,omitempty"`
+	MergedBefore  int64  `json:"mergedbefore,omitempty"`
+	ClosedAfter   int64  `json:"closedafter,omitempty"`
+	ClosedBefore  int64  `json:"closedbefore,omitempty"`
+	UpdatedAfter  int64  `json:"updatedafter,omitempty"`
+	UpdatedBefore int64  `json:"updatedbefore,omitempty"`
+	Sort          string `json:"sort,omitempty"`
+	Order         string `json:"order,omitempty"`
+}
+
+// ListPullRequestsCmd describes the command and parameters for performing the
+// listpullrequests method.
+type ListPullRequestsCmd struct {
+	Filter *PullRequestFilter `json:"filter"`
+	Cursor *string            `json:"cursor"`
+	Limit  *int               `json:"limit" jsonrpcdefault:"100"`
+}
+
+// AddAliasCmd describes the command and parameters for performing the
+// addalias method.  Type is either login or email.
+type AddAliasCmd struct {
+	Contributor string `json:"contributor"`
+	Type        string `json:"type"`
+	Value       string `json:"value"`
+}
+
+// RemoveAliasCmd describes the command and parameters for performing the
+// removealias method.
+type RemoveAliasCmd struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// ListAliasesCmd describes the command and parameters for performing the
+// listaliases method.  All aliases are listed when no contributor is passed.
+type ListAliasesCmd struct {
+	Contributor *string `json:"contributor"`
+}
+
+// PRHistoryCmd describes the command and parameters for performing the
+// prhistory method.
+type PRHistoryCmd struct {
+	URL string `json:"url"`
+}
+
+// SnapshotMonthCmd describes the command and parameters for performing the
+// snapshotmonth method.
+type SnapshotMonthCmd struct {
+	Org   string `json:"org"`
+	Year  int    `json:"year"`
+	Month int    `json:"month"`
+}
+
+// OrgSummaryCmd describes the command and parameters for performing the
+// orgsummary method.
+type OrgSummaryCmd struct {
+	Org   string `json:"org"`
+	Year  int    `json:"year"`
+	Month int    `json:"month"`
+}
+
+// UserSummaryCmd describes the command and parameters for performing the
+// usersummary method.
+type UserSummaryCmd struct {
 	User  string `json:"user"`
 	Org   string `json:"org"`
 	Year  int    `json:"year"`
 	Month int    `json:"month"`
 }
 
+// PurgeUserCmd describes the command and parameters for performing the
+// purgeuser method.
+type PurgeUserCmd struct {
+	User string `json:"user"`
+}
+
+// PseudonymizeUserCmd describes the command and parameters for performing the
+// pseudonymizeuser method.
+type PseudonymizeUserCmd struct {
+	User string `json:"user"`
+}
+
+// CreateAPIKeyCmd describes the command and parameters for performing the
+// createapikey method.  Scope is one of read, sync or admin and Expires is the
+// optional UNIX timestamp after which the key is rejected.
+type CreateAPIKeyCmd struct {
+	Name    string `json:"name"`
+	Scope   string `json:"scope"`
+	Expires *int64 `json:"expires"`
+}
+
+// RevokeAPIKeyCmd describes the command and parameters for performing the
+// revokeapikey method.
+type RevokeAPIKeyCmd struct {
+	ID string `json:"id"`
+}
+
+// ListAPIKeysCmd describes the command for performing the listapikeys method.
+type ListAPIKeysCmd struct{}
+
+// HelpCmd describes the command and parameters for performing the help
+// method.  The usage of every method is listed when Command is not set.
+type HelpCmd struct {
+	Command *string `json:"command"`
+}
+
+// VersionCmd describes the command for performing the version method.
+type VersionCmd struct{}
+
+// StatusCmd describes the command for performing the status method.
+type StatusCmd struct{}
+
+// StopCmd describes the command for performing the stop method.
+type StopCmd struct{}
+
+// NotifySyncProgressCmd describes the command for performing the
+// notifysyncprogress method, which subscribes a websocket client to the
+// syncprogress notifications.
+type NotifySyncProgressCmd struct{}
+
+// NotifyNewMergesCmd describes the command for performing the notifynewmerges
+// method, which subscribes a websocket client to the newmerges notifications.
+type NotifyNewMergesCmd struct{}
+
 type registeredMethod struct {
 	method string
 	cmd    interface{}
@@ -39,4 +174,26 @@ func init() {
 	flags := dcrjson.UsageFlag(0)
 	dcrjson.MustRegister(Method("update"), (*UpdateCmd)(nil), flags)
 	dcrjson.MustRegister(Method("userinformation"), (*UserInformationCmd)(nil), flags)
+	dcrjson.MustRegister(Method("listpullrequests"), (*ListPullRequestsCmd)(nil), flags)
+	dcrjson.MustRegister(Method("addalias"), (*AddAliasCmd)(nil), flags)
+	dcrjson.MustRegister(Method("removealias"), (*RemoveAliasCmd)(nil), flags)
+	dcrjson.MustRegister(Method("listaliases"), (*ListAliasesCmd)(nil), flags)
+	dcrjson.MustRegister(Method("prhistory"), (*PRHistoryCmd)(nil), flags)
+	dcrjson.MustRegister(Method("snapshotmonth"), (*SnapshotMonthCmd)(nil), flags)
+	dcrjson.MustRegister(Method("orgsummary"), (*OrgSummaryCmd)(nil), flags)
+	dcrjson.MustRegister(Method("usersummary"), (*UserSummaryCmd)(nil), flags)
+	dcrjson.MustRegister(Method("purgeuser"), (*PurgeUserCmd)(nil), flags)
+	dcrjson.MustRegister(Method("pseudonymizeuser"), (*PseudonymizeUserCmd)(nil), flags)
+	dcrjson.MustRegister(Method("createapikey"), (*CreateAPIKeyCmd)(nil), flags)
+	dcrjson.MustRegister(Method("revokeapikey"), (*RevokeAPIKeyCmd)(nil), flags)
+	dcrjson.MustRegister(Method("listapikeys"), (*ListAPIKeysCmd)(nil), flags)
+	dcrjson.MustRegister(Method("help"), (*HelpCmd)(nil), flags)
+	dcrjson.MustRegister(Method("version"), (*VersionCmd)(nil), flags)
+	dcrjson.MustRegister(Method("status"), (*StatusCmd)(nil), flags)
+	dcrjson.MustRegister(Method("stop"), (*StopCmd)(nil), flags)
+
+	// Websocket only methods.
+	wsFlags := dcrjson.UFWebsocketOnly
+	dcrjson.MustRegister(Method("notifysyncprogress"), (*NotifySyncProgressCmd)(nil), wsFlags)
+	dcrjson.MustRegister(Method("notifynewmerges"), (*NotifyNewMergesCmd)(nil), wsFlags)
 }
@@ -0,0 +1,56 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// NOTE: This file is intended to house the RPC websocket notifications that are
+// supported by the server.
+
+package types
+
+import (
+	"github.com/decred/dcrd/dcrjson/v3"
+)
+
+const (
+	// SyncProgressNtfnMethod is the method used for notifications of the
+	// progress of an organization sync.  Clients subscribe to it with the
+	// notifysyncprogress method.
+	SyncProgressNtfnMethod Method = "syncprogress"
+
+	// NewMergesNtfnMethod is the method used for notifications of pull
+	// requests that a sync found to be newly merged.  Clients subscribe
+	// to it with the notifynewmerges method.
+	NewMergesNtfnMethod Method = "newmerges"
+)
+
+// SyncProgressNtfn is a type handling custom marshaling and unmarshaling of
+// syncprogress JSON websocket notifications.  A notification is sent before
+// every repository of the organization is synced and once the sync is done.
+// Error is only set when the sync failed.
+type SyncProgressNtfn struct {
+	Organization string `json:"organization"`
+	Repository   string `json:"repository"` // Repository being synced, empty once done
+	Synced       int    `json:"synced"`     // Number of repositories already synced
+	Total        int    `json:"total"`      // Number of repositories of the organization
+	Done         bool   `json:"done"`
+	Error        string `json:"error"`
+}
+
+// NewMergesNtfn is a type handling custom marshaling and unmarshaling of
+// newmerges JSON websocket notifications.  A notification is sent once a
+// repository is synced when any of its pull requests was merged since the
+// previous sync.
+type NewMergesNtfn struct {
+	Organization string                   `json:"organization"`
+	Repository   string                   `json:"repository"`
+	PullRequests []PullRequestInformation `json:"pullrequests"`
+}
+
+func init() {
+	// The commands in this file are only usable by websockets and are
+	// notifications.
+	flags := dcrjson.UFWebsocketOnly | dcrjson.UFNotification
+
+	dcrjson.MustRegister(SyncProgressNtfnMethod, (*SyncProgressNtfn)(nil), flags)
+	dcrjson.MustRegister(NewMergesNtfnMethod, (*NewMergesNtfn)(nil), flags)
+}
@@ -12,10 +12,132 @@ type UpdateResult struct {
 // UserInformationResult models the data from the userinformation command.
 type UserInformationResult struct {
 	User         string                   `json:"user"`
+	Contributor  string                   `json:"contributor,omitempty"`
+	Logins       []string                 `json:"logins,omitempty"`
+	Emails       []string                 `json:"emails,omitempty"`
 	Organization string                   `json:"organization"`
 	PRs          []PullRequestInformation `json:"prs"`
 	RepoDetails  []RepositoryInformation  `json:"repodetails"`
 	Reviews      []ReviewInformation      `json:"reviews"`
+	Commits      []CommitInformation      `json:"commits"`
+	Snapshot     *SnapshotInformation     `json:"snapshot,omitempty"` // Set when the result is a frozen snapshot
+}
+
+// SnapshotMonthResult models the data from the snapshotmonth command.
+type SnapshotMonthResult struct {
+	Organization string                `json:"organization"`
+	Year         int                   `json:"year"`
+	Month        int                   `json:"month"`
+	Snapshots    []SnapshotInformation `json:"snapshots"`
+}
+
+// SnapshotInformation describes a frozen user information snapshot.  Hash is
+// the hex encoded SHA-256 of the serialized user information.
+type SnapshotInformation struct {
+	User string `json:"user,omitempty"`
+	Hash string `json:"hash"`
+	Date string `json:"date"`
+}
+
+// PurgeUserResult models the data from the purgeuser command.
+type PurgeUserResult struct {
+	User   string   `json:"user"`
+	Logins []string `json:"logins"`
+	Emails []string `json:"emails,omitempty"`
+}
+
+// PseudonymizeUserResult models the data from the pseudonymizeuser command.
+type PseudonymizeUserResult struct {
+	User      string   `json:"user"`
+	Pseudonym string   `json:"pseudonym"`
+	Logins    []string `json:"logins"`
+}
+
+// PRHistoryResult models the data from the prhistory command.
+type PRHistoryResult struct {
+	URL     string              `json:"url"`
+	Changes []ChangeInformation `json:"changes"`
+}
+
+// ChangeInformation describes a change that sync applied to a pull request,
+// review or commit.  Field, Old and New are only set for updates.
+type ChangeInformation struct {
+	PullRequest string `json:"pullrequest"`
+	Record      string `json:"record"`
+	RecordID    string `json:"recordid,omitempty"`
+	Action      string `json:"action"`
+	Field       string `json:"field,omitempty"`
+	Old         string `json:"old,omitempty"`
+	New         string `json:"new,omitempty"`
+	Date        string `json:"date"`
+}
+
+// CreateAPIKeyResult models the data from the createapikey command.  Key is
+// only returned once and can not be recovered later.
+type CreateAPIKeyResult struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Key       string `json:"key"`
+	Scope     string `json:"scope"`
+	ExpiresAt int64  `json:"expiresat,omitempty"`
+}
+
+// ListAPIKeysResult models the data from the listapikeys command.
+type ListAPIKeysResult struct {
+	Keys []APIKeyInformation `json:"keys"`
+}
+
+// APIKeyInformation describes an API key without the key itself.
+type APIKeyInformation struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Scope      string `json:"scope"`
+	CreatedAt  int64  `json:"createdat"`
+	ExpiresAt  int64  `json:"expiresat,omitempty"`
+	LastUsedAt int64  `json:"lastusedat,omitempty"`
+}
+
+// StatusResult models the data from the status command.  DBError is set when
+// the database is unreachable, in which case Organizations is empty.
+type StatusResult struct {
+	Uptime        int64                    `json:"uptime"` // Seconds since the server started
+	DBConnected   bool                     `json:"dbconnected"`
+	DBError       string                   `json:"dberror,omitempty"`
+	RateLimit     RateLimitInformation     `json:"ratelimit"`
+	Organizations []OrganizationSyncStatus `json:"organizations"`
+}
+
+// RateLimitInformation describes the last known GitHub rate limit of the
+// core API.  It is zero until the first request to GitHub is made.
+type RateLimitInformation struct {
+	Limit     int   `json:"limit"`
+	Remaining int   `json:"remaining"`
+	Reset     int64 `json:"reset"` // UNIX timestamp at which the limit is reset
+}
+
+// OrganizationSyncStatus describes when an organization was last synced.
+// LastSync is zero when it was never completely synced.
+type OrganizationSyncStatus struct {
+	Organization string `json:"organization"`
+	LastSync     int64  `json:"lastsync"`
+}
+
+// ListAliasesResult models the data from the listaliases command.
+type ListAliasesResult struct {
+	Aliases []AliasInformation `json:"aliases"`
+}
+
+type AliasInformation struct {
+	Contributor string `json:"contributor"`
+	Type        string `json:"type"`
+	Value       string `json:"value"`
+}
+
+// ListPullRequestsResult models the data from the listpullrequests command.
+// NextCursor is empty when there are no more pull requests to list.
+type ListPullRequestsResult struct {
+	PullRequests []PullRequestInformation `json:"pullrequests"`
+	NextCursor   string                   `json:"nextcursor,omitempty"`
 }
 
 type RepositoryInformation struct {
@@ -29,14 +151,65 @@ type RepositoryInformation struct {
 	ReviewDeletions int64    `json:"reviewdeletions"`
 }
 
-type PullRequestInformation struct {
+// SummaryStats holds the totals of a user or repository for a month.
+type SummaryStats struct {
+	PRs             int64 `json:"prs"`
+	MergeAdditions  int64 `json:"mergeadditions"`
+	MergeDeletions  int64 `json:"mergedeletions"`
+	Reviews         int64 `json:"reviews"`
+	ReviewAdditions int64 `json:"reviewadditions"`
+	ReviewDeletions int64 `json:"reviewdeletions"`
+	Commits         int64 `json:"commits"`
+	CommitAdditions int64 `json:"commitadditions"`
+	CommitDeletions int64 `json:"commitdeletions"`
+}
+
+// RepositorySummary holds the totals of a user in a repository for a month.
+type RepositorySummary struct {
 	Repository string `json:"repo"`
-	URL        string `json:"url"`
-	Number     int    `json:"number"`
-	Additions  int64  `json:"additions"`
-	Deletions  int64  `json:"deletions"`
-	Date       string `json:"date"`
-	State      string `json:"state"`
+	SummaryStats
+}
+
+// UserSummaryResult models the data from the usersummary command and the
+// per-user totals of the orgsummary command.
+type UserSummaryResult struct {
+	User         string `json:"user"`
+	Organization string `json:"organization,omitempty"`
+	Year         int    `json:"year,omitempty"`
+	Month        int    `json:"month,omitempty"`
+	SummaryStats
+	Repositories []RepositorySummary `json:"repositories"`
+}
+
+// OrgSummaryResult models the data from the orgsummary command.
+type OrgSummaryResult struct {
+	Organization string `json:"organization"`
+	Year         int    `json:"year"`
+	Month        int    `json:"month"`
+	SummaryStats
+	Users []UserSummaryResult `json:"users"`
+}
+
+type PullRequestInformation struct {
+	Repository         string   `json:"repo"`
+	Author             string   `json:"author,omitempty"`
+	URL                string   `json:"url"`
+	Number             int      `json:"number"`
+	Title              string   `json:"title"`
+	Body               string   `json:"body,omitempty"`
+	Labels             []string `json:"labels,omitempty"`
+	BaseRef            string   `json:"baseref"`
+	HeadRef            string   `json:"headref"`
+	HeadRepository     string   `json:"headrepo"`
+	Fork               bool     `json:"fork"`
+	Draft              bool     `json:"draft"`
+	CreatedAt          int64    `json:"createdat"`
+	RequestedReviewers []string `json:"requestedreviewers,omitempty"`
+	Milestone          string   `json:"milestone,omitempty"`
+	Additions          int64    `json:"additions"`
+	Deletions          int64    `json:"deletions"`
+	Date               string   `json:"date"`
+	State              string   `json:"state"`
 }
 
 type ReviewInformation struct {
@@ -48,3 +221,15 @@ type ReviewInformation struct {
 	Date       string `json:"date"`
 	State      string `json:"state"`
 }
+
+type CommitInformation struct {
+	Repository  string `json:"repo"`
+	SHA         string `json:"sha"`
+	URL         string `json:"url"`
+	PullRequest string `json:"pullrequest"`
+	Author      string `json:"author"`
+	AuthorEmail string `json:"authoremail"`
+	Additions   int    `json:"additions"`
+	Deletions   int    `json:"deletions"`
+	Date        string `json:"date"`
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/decred/github-tracker/api"
 	db "github.com/decred/github-tracker/database/cockroachdb"
 	"github.com/decred/github-tracker/jsonrpc"
+	"github.com/decred/github-tracker/rpc/rpcserver"
 	"github.com/decred/github-tracker/server"
 
 	"github.com/decred/slog"
@@ -52,6 +53,7 @@ var (
 	apiLog     = backendLog.Logger("APIS")
 	dbLOG      = backendLog.Logger("DB")
 	serverLog  = backendLog.Logger("GSVR")
+	grpcLog    = backendLog.Logger("GRPC")
 )
 
 // Initialize package-global logger variables.
@@ -60,6 +62,7 @@ func init() {
 	api.UseLogger(apiLog)
 	db.UseLogger(dbLOG)
 	server.UseLogger(serverLog)
+	rpcserver.UseLogger(grpcLog)
 }
 
 // subsystemLoggers maps each subsystem identifier to its associated logger.
@@ -69,6 +72,7 @@ var subsystemLoggers = map[string]slog.Logger{
 	"RPCS": jsonrpcLog,
 	"DB":   dbLOG,
 	"GSVR": serverLog,
+	"GRPC": grpcLog,
 }
 
 // initLogRotator initializes the logging rotater to write logs to logFile and
@@ -0,0 +1,87 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/decred/github-tracker/api"
+	db "github.com/decred/github-tracker/database/cockroachdb"
+	"github.com/decred/github-tracker/jsonrpc"
+	"github.com/decred/github-tracker/server"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// newMetricsRegistry returns a Prometheus registry with the collectors of the
+// Go runtime, the process and every instrumented package registered.
+func newMetricsRegistry() (*prometheus.Registry, error) {
+	registry := prometheus.NewRegistry()
+	err := registry.Register(collectors.NewGoCollector())
+	if err != nil {
+		return nil, err
+	}
+	err = registry.Register(collectors.NewProcessCollector(
+		collectors.ProcessCollectorOpts{}))
+	if err != nil {
+		return nil, err
+	}
+	registerFns := []func(prometheus.Registerer) error{
+		api.RegisterMetrics,
+		server.RegisterMetrics,
+		db.RegisterMetrics,
+		jsonrpc.RegisterMetrics,
+	}
+	for _, register := range registerFns {
+		if err := register(registry); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
+
+// startMetricsServer serves the metrics in the Prometheus text format at
+// /metrics on every metrics listener.  It returns nil when no metrics
+// listeners are configured.
+func startMetricsServer(cfg *config) (*http.Server, error) {
+	if len(cfg.MetricsListeners) == 0 {
+		return nil, nil
+	}
+
+	registry, err := newMetricsRegistry()
+	if err != nil {
+		return nil, err
+	}
+	listeners := makeListeners(cfg.MetricsListeners, net.Listen)
+	if len(listeners) == 0 {
+		return nil, errors.New("failed to create listeners for metrics server")
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+		ErrorLog: promLogger{},
+	}))
+	srv := &http.Server{Handler: mux}
+	for _, lis := range listeners {
+		lis := lis
+		go func() {
+			log.Infof("Metrics server listening on %s", lis.Addr())
+			err := srv.Serve(lis)
+			log.Tracef("Finished serving metrics: %v", err)
+		}()
+	}
+	return srv, nil
+}
+
+// promLogger logs the errors of the metrics handler.
+type promLogger struct{}
+
+// Println satisfies the promhttp.Logger interface.
+func (promLogger) Println(v ...interface{}) {
+	log.Error(v...)
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpcserver
+
+import (
+	"github.com/decred/github-tracker/jsonrpc/types"
+	"github.com/decred/github-tracker/rpc/trackerrpc"
+)
+
+func convertUserInformation(userInfo *types.UserInformationResult) *trackerrpc.UserStatsResponse {
+	resp := &trackerrpc.UserStatsResponse{
+		User:         userInfo.User,
+		Contributor:  userInfo.Contributor,
+		Logins:       userInfo.Logins,
+		Emails:       userInfo.Emails,
+		Organization: userInfo.Organization,
+		PullRequests: convertPullRequests(userInfo.PRs),
+		Repositories: make([]*trackerrpc.RepositoryStats, 0, len(userInfo.RepoDetails)),
+		Reviews:      make([]*trackerrpc.Review, 0, len(userInfo.Reviews)),
+		Commits:      make([]*trackerrpc.Commit, 0, len(userInfo.Commits)),
+	}
+	for i := range userInfo.RepoDetails {
+		repo := &userInfo.RepoDetails[i]
+		resp.Repositories = append(resp.Repositories, &trackerrpc.RepositoryStats{
+			Repository:      repo.Repository,
+			PullRequests:    repo.PRs,
+			CommitAdditions: repo.CommitAdditions,
+			CommitDeletions: repo.CommitDeletions,
+			MergeAdditions:  repo.MergeAdditions,
+			MergeDeletions:  repo.MergeDeletions,
+			ReviewAdditions: repo.ReviewAdditions,
+			ReviewDeletions: repo.ReviewDeletions,
+		})
+	}
+	for i := range userInfo.Reviews {
+		review := &userInfo.Reviews[i]
+		resp.Reviews = append(resp.Reviews, &trackerrpc.Review{
+			Repository: review.Repository,
+			Url:        review.URL,
+			Number:     int32(review.Number),
+			Additions:  int64(review.Additions),
+			Deletions:  int64(review.Deletions),
+			Date:       review.Date,
+			State:      review.State,
+		})
+	}
+	for i := range userInfo.Commits {
+		commit := &userInfo.Commits[i]
+		resp.Commits = append(resp.Commits, &trackerrpc.Commit{
+			Repository:  commit.Repository,
+			Sha:         commit.SHA,
+			Url:         commit.URL,
+			PullRequest: commit.PullRequest,
+			Author:      commit.Author,
+			AuthorEmail: commit.AuthorEmail,
+			Additions:   int64(commit.Additions),
+			Deletions:   int64(commit.Deletions),
+			Date:        commit.Date,
+		})
+	}
+	if userInfo.Snapshot != nil {
+		resp.Snapshot = &trackerrpc.Snapshot{
+			Hash: userInfo.Snapshot.Hash,
+			Date: userInfo.Snapshot.Date,
+		}
+	}
+	return resp
+}
+
+func convertPullRequests(prs []types.PullRequestInformation) []*trackerrpc.PullRequest {
+	rpcPRs := make([]*trackerrpc.PullRequest, 0, len(prs))
+	for i := range prs {
+		pr := &prs[i]
+		rpcPRs = append(rpcPRs, &trackerrpc.PullRequest{
+			Repository:         pr.Repository,
+			Author:             pr.Author,
+			Url:                pr.URL,
+			Number:             int32(pr.Number),
+			Title:              pr.Title,
+			Body:               pr.Body,
+			Labels:             pr.Labels,
+			BaseRef:            pr.BaseRef,
+			HeadRef:            pr.HeadRef,
+			HeadRepository:     pr.HeadRepository,
+			Fork:               pr.Fork,
+			Draft:              pr.Draft,
+			CreatedAt:          pr.CreatedAt,
+			RequestedReviewers: pr.RequestedReviewers,
+			Milestone:          pr.Milestone,
+			Additions:          pr.Additions,
+			Deletions:          pr.Deletions,
+			Date:               pr.Date,
+			State:              pr.State,
+		})
+	}
+	return rpcPRs
+}
+
+// convertPullRequestFilter returns the server filter of a request filter.  A
+// nil filter matches every pull request.
+func convertPullRequestFilter(filter *trackerrpc.PullRequestFilter) types.PullRequestFilter {
+	if filter == nil {
+		return types.PullRequestFilter{}
+	}
+	return types.PullRequestFilter{
+		Org:           filter.Organization,
+		Repo:          filter.Repository,
+		Author:        filter.Author,
+		State:         filter.State,
+		Label:         filter.Label,
+		MergedAfter:   filter.MergedAfter,
+		MergedBefore:  filter.MergedBefore,
+		ClosedAfter:   filter.ClosedAfter,
+		ClosedBefore:  filter.ClosedBefore,
+		UpdatedAfter:  filter.UpdatedAfter,
+		UpdatedBefore: filter.UpdatedBefore,
+		Sort:          filter.Sort,
+		Order:         filter.Order,
+	}
+}
+
+func convertSyncProgress(progress *types.SyncProgressNtfn) *trackerrpc.SyncProgress {
+	return &trackerrpc.SyncProgress{
+		Organization: progress.Organization,
+		Repository:   progress.Repository,
+		Synced:       int32(progress.Synced),
+		Total:        int32(progress.Total),
+		Done:         progress.Done,
+		Error:        progress.Error,
+	}
+}
@@ -0,0 +1,252 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package rpcserver implements the TrackerService gRPC service described by
+// the trackerrpc package.
+package rpcserver
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+
+	"github.com/decred/github-tracker/database"
+	"github.com/decred/github-tracker/jsonrpc"
+	"github.com/decred/github-tracker/jsonrpc/types"
+	"github.com/decred/github-tracker/rpc/trackerrpc"
+	"github.com/decred/github-tracker/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// defaultPullRequestLimit is the number of pull requests listed when a
+// ListPullRequests request does not set a limit.
+const defaultPullRequestLimit = 100
+
+// Authenticator identifies the caller presenting an Authorization header value
+// on a TLS connection.  It is implemented by *jsonrpc.Authenticator, so that
+// callers hold the same roles on the gRPC and JSON-RPC servers.
+type Authenticator interface {
+	Authenticate(ctx context.Context, auth string, state *tls.ConnectionState) (string, jsonrpc.Role, error)
+}
+
+// methodRoles maps each method of the service to the role the caller must be
+// granted.
+var methodRoles = map[string]jsonrpc.Role{
+	trackerrpc.TrackerService_UserStats_FullMethodName:        jsonrpc.RoleReader,
+	trackerrpc.TrackerService_ListPullRequests_FullMethodName: jsonrpc.RoleReader,
+	trackerrpc.TrackerService_Sync_FullMethodName:             jsonrpc.RoleOperator,
+	trackerrpc.TrackerService_WatchSync_FullMethodName:        jsonrpc.RoleReader,
+}
+
+// NewServer returns a gRPC server with the tracker service of s registered.
+// Every call is authenticated by auth and must be made by a caller granted
+// the role of the method.
+func NewServer(s *server.Server, auth Authenticator, opts ...grpc.ServerOption) *grpc.Server {
+	a := &authorizer{auth: auth}
+	opts = append(opts, grpc.UnaryInterceptor(a.unary),
+		grpc.StreamInterceptor(a.stream))
+	grpcServer := grpc.NewServer(opts...)
+	trackerrpc.RegisterTrackerServiceServer(grpcServer, &trackerService{server: s})
+	return grpcServer
+}
+
+// authorizer checks the credentials and role of callers.
+type authorizer struct {
+	auth Authenticator
+}
+
+// authorize authenticates the caller of the passed method from the
+// authorization metadata or the TLS client certificate of the call and checks
+// that it is granted the role of the method.
+func (a *authorizer) authorize(ctx context.Context, method string) error {
+	role, ok := methodRoles[method]
+	if !ok {
+		return status.Errorf(codes.PermissionDenied,
+			"method %v is not permitted", method)
+	}
+
+	var auth string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) != 0 {
+			auth = values[0]
+		}
+	}
+	var addr string
+	var state *tls.ConnectionState
+	if p, ok := peer.FromContext(ctx); ok {
+		addr = p.Addr.String()
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			state = &info.State
+		}
+	}
+
+	name, granted, err := a.auth.Authenticate(ctx, auth, state)
+	if err != nil {
+		log.Warnf("Failed authentication attempt from client %s", addr)
+		return status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+	if granted < role {
+		return status.Errorf(codes.PermissionDenied,
+			"method %v requires the %v role", method, role)
+	}
+	log.Infof("RPC method %v invoked by %v (%v)", method, addr, name)
+	return nil
+}
+
+func (a *authorizer) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	jsonrpc.CountCall(info.FullMethod)
+	if err := a.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *authorizer) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	jsonrpc.CountCall(info.FullMethod)
+	if err := a.authorize(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// translateError returns the gRPC status error of an error returned by the
+// server.  Errors caused by invalid arguments are reported as such.
+func translateError(err error) error {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, server.ErrInvalidMonth),
+		errors.Is(err, server.ErrInvalidUser),
+		errors.Is(err, server.ErrInvalidFilter),
+		errors.Is(err, database.ErrInvalidCursor):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, database.ErrSnapshotNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, server.ErrStopping):
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		return status.Error(codes.Unknown, err.Error())
+	}
+}
+
+// trackerService implements trackerrpc.TrackerServiceServer.
+type trackerService struct {
+	trackerrpc.UnimplementedTrackerServiceServer
+
+	server *server.Server
+}
+
+// UserStats returns the pull requests, reviews and commits of a user for a
+// month, or the frozen snapshot of the month.
+func (t *trackerService) UserStats(ctx context.Context, req *trackerrpc.UserStatsRequest) (*trackerrpc.UserStatsResponse, error) {
+	var userInfo *types.UserInformationResult
+	var err error
+	if req.Snapshot {
+		userInfo, err = t.server.UserSnapshot(ctx, req.Organization,
+			req.User, int(req.Year), int(req.Month))
+	} else {
+		userInfo, err = t.server.UserInformation(ctx, req.Organization,
+			req.User, int(req.Year), int(req.Month))
+	}
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return convertUserInformation(userInfo), nil
+}
+
+// ListPullRequests returns a page of the pull requests matching the filter of
+// the request.
+func (t *trackerService) ListPullRequests(ctx context.Context, req *trackerrpc.ListPullRequestsRequest) (*trackerrpc.ListPullRequestsResponse, error) {
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = defaultPullRequestLimit
+	}
+	result, err := t.server.ListPullRequests(ctx,
+		convertPullRequestFilter(req.Filter), req.Cursor, limit)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &trackerrpc.ListPullRequestsResponse{
+		PullRequests: convertPullRequests(result.PullRequests),
+		NextCursor:   result.NextCursor,
+	}, nil
+}
+
+// Sync syncs an organization and streams the progress of the sync until it is
+// done.
+func (t *trackerService) Sync(req *trackerrpc.SyncRequest, stream trackerrpc.TrackerService_SyncServer) error {
+	if req.Organization == "" {
+		return status.Error(codes.InvalidArgument, "missing organization")
+	}
+
+	ctx := stream.Context()
+	sub := t.server.Subscribe()
+	defer sub.Close()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- t.server.Update(ctx, req.Organization)
+	}()
+
+	// Progress is forwarded until the sync is done.  Update publishes the
+	// final progress before it returns, so the progress still queued is
+	// forwarded afterwards.  A failed send ends the call, which cancels the
+	// sync through the context of the stream.
+	send := func(ntfn interface{}) error {
+		progress, ok := ntfn.(*types.SyncProgressNtfn)
+		if !ok || progress.Organization != req.Organization {
+			return nil
+		}
+		return stream.Send(convertSyncProgress(progress))
+	}
+	for {
+		select {
+		case ntfn := <-sub.C:
+			if err := send(ntfn); err != nil {
+				return err
+			}
+		case err := <-errc:
+			for len(sub.C) != 0 {
+				if err := send(<-sub.C); err != nil {
+					return err
+				}
+			}
+			if err != nil {
+				return translateError(err)
+			}
+			return nil
+		}
+	}
+}
+
+// WatchSync streams the progress of all syncs until the client cancels the
+// call.
+func (t *trackerService) WatchSync(req *trackerrpc.WatchSyncRequest, stream trackerrpc.TrackerService_WatchSyncServer) error {
+	ctx := stream.Context()
+	sub := t.server.Subscribe()
+	defer sub.Close()
+
+	for {
+		select {
+		case ntfn, ok := <-sub.C:
+			if !ok {
+				return nil
+			}
+			progress, ok := ntfn.(*types.SyncProgressNtfn)
+			if !ok {
+				continue
+			}
+			if err := stream.Send(convertSyncProgress(progress)); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
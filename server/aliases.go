@@ -0,0 +1,176 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/decred/github-tracker/database"
+	"github.com/decred/github-tracker/jsonrpc/types"
+)
+
+var (
+	// ErrInvalidAlias is returned when an alias type or value is invalid.
+	ErrInvalidAlias = errors.New("invalid alias")
+
+	// ErrInvalidUser is returned when a login or contributor name is
+	// empty.
+	ErrInvalidUser = errors.New("invalid user")
+)
+
+// normalizeAlias validates the passed alias type and returns the normalized
+// alias value.  Emails are compared case insensitively and are therefore
+// stored in lower case.
+func normalizeAlias(aliasType, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", fmt.Errorf("%w: empty value", ErrInvalidAlias)
+	}
+	switch aliasType {
+	case database.AliasLogin:
+		return value, nil
+	case database.AliasEmail:
+		return strings.ToLower(value), nil
+	default:
+		return "", fmt.Errorf("%w: type %q", ErrInvalidAlias, aliasType)
+	}
+}
+
+// AddAlias assigns a login or email to a contributor.
+func (s *Server) AddAlias(ctx context.Context, contributor, aliasType, value string) error {
+	contributor = strings.TrimSpace(contributor)
+	if contributor == "" {
+		return fmt.Errorf("%w: empty contributor", ErrInvalidAlias)
+	}
+	value, err := normalizeAlias(aliasType, value)
+	if err != nil {
+		return err
+	}
+	return s.DB.NewAlias(ctx, &database.Alias{
+		Contributor: contributor,
+		Type:        aliasType,
+		Value:       value,
+	})
+}
+
+// RemoveAlias removes a login or email from the contributor it is assigned
+// to.
+func (s *Server) RemoveAlias(ctx context.Context, aliasType, value string) error {
+	value, err := normalizeAlias(aliasType, value)
+	if err != nil {
+		return err
+	}
+	return s.DB.DeleteAlias(ctx, aliasType, value)
+}
+
+// ListAliases returns the aliases of the passed contributor, or all aliases
+// when the contributor is empty.
+func (r *Reader) ListAliases(ctx context.Context, contributor string) (*types.ListAliasesResult, error) {
+	var dbAliases []database.Alias
+	var err error
+	if contributor = strings.TrimSpace(contributor); contributor == "" {
+		dbAliases, err = r.db.Aliases(ctx)
+	} else {
+		dbAliases, err = r.db.AliasesByContributor(ctx, contributor)
+	}
+	if err != nil {
+		return nil, err
+	}
+	aliases := make([]types.AliasInformation, 0, len(dbAliases))
+	for _, alias := range dbAliases {
+		aliases = append(aliases, types.AliasInformation{
+			Contributor: alias.Contributor,
+			Type:        alias.Type,
+			Value:       alias.Value,
+		})
+	}
+	return &types.ListAliasesResult{
+		Aliases: aliases,
+	}, nil
+}
+
+// identities describes all logins and emails that belong to a contributor.
+type identities struct {
+	contributor string
+	logins      []string
+	emails      []string
+	userIDs     []int64 // Known GitHub accounts of the logins
+}
+
+// name returns the name that identifies the identities: the contributor their
+// aliases are assigned to, or the current login of the account when there are
+// no aliases.
+func (id *identities) name() string {
+	if id.contributor != "" {
+		return id.contributor
+	}
+	return id.logins[0]
+}
+
+// contributorIdentities resolves a login or contributor name to all of the
+// logins and emails registered as aliases of the contributor.  Every login is
+// further expanded to all of the logins its GitHub account has been known by
+// and to the ID of that account.
+// The login itself is used when it is not part of the alias registry.
+// ErrInvalidUser is returned when the login is empty.
+func (r *Reader) contributorIdentities(ctx context.Context, login string) (*identities, error) {
+	login = strings.TrimSpace(login)
+	if login == "" {
+		return nil, fmt.Errorf("%w: empty login or contributor",
+			ErrInvalidUser)
+	}
+
+	id := &identities{}
+	contributor, err := r.db.ContributorByAlias(ctx, database.AliasLogin, login)
+	switch {
+	case err == database.ErrAliasNotFound:
+		// The login may also be the name of the contributor itself.
+		contributor = login
+	case err != nil:
+		return nil, err
+	}
+	aliases, err := r.db.AliasesByContributor(ctx, contributor)
+	if err != nil {
+		return nil, err
+	}
+	if len(aliases) > 0 {
+		id.contributor = contributor
+	}
+
+	logins := []string{login}
+	for _, alias := range aliases {
+		switch alias.Type {
+		case database.AliasLogin:
+			logins = append(logins, alias.Value)
+		case database.AliasEmail:
+			id.emails = append(id.emails, alias.Value)
+		}
+	}
+
+	seen := make(map[string]struct{}, len(logins))
+	seenIDs := make(map[int64]struct{}, len(logins))
+	for _, login := range logins {
+		accountLogins, userID, err := r.userLogins(ctx, login)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seenIDs[userID]; userID != 0 && !ok {
+			seenIDs[userID] = struct{}{}
+			id.userIDs = append(id.userIDs, userID)
+		}
+		for _, l := range accountLogins {
+			if _, ok := seen[l]; ok {
+				continue
+			}
+			seen[l] = struct{}{}
+			id.logins = append(id.logins, l)
+		}
+	}
+
+	return id, nil
+}
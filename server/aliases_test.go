@@ -0,0 +1,58 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/decred/github-tracker/database"
+)
+
+func TestNormalizeAlias(t *testing.T) {
+	tests := []struct {
+		name      string
+		aliasType string
+		value     string
+		want      string
+		err       error
+	}{
+		{
+			name:      "login",
+			aliasType: database.AliasLogin,
+			value:     " Alice ",
+			want:      "Alice",
+		},
+		{
+			name:      "email",
+			aliasType: database.AliasEmail,
+			value:     "Alice@Example.COM",
+			want:      "alice@example.com",
+		},
+		{
+			name:      "empty",
+			aliasType: database.AliasLogin,
+			value:     " \t",
+			err:       ErrInvalidAlias,
+		},
+		{
+			name:      "type",
+			aliasType: "name",
+			value:     "Alice",
+			err:       ErrInvalidAlias,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := normalizeAlias(test.aliasType, test.value)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("got error %v, want %v", err, test.err)
+			}
+			if got != test.want {
+				t.Fatalf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
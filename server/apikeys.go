@@ -0,0 +1,160 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/decred/github-tracker/database"
+	"github.com/decred/github-tracker/jsonrpc/types"
+)
+
+const (
+	// apiKeyPrefix is the prefix of every API key, which makes keys easy
+	// to recognize in configuration and by secret scanners.
+	apiKeyPrefix = "ghtk_"
+
+	// apiKeyUsedInterval is the minimum duration between updates of the
+	// last used timestamp of a key, so that not every request writes to
+	// the database.
+	apiKeyUsedInterval = time.Minute
+)
+
+var (
+	// ErrInvalidAPIKey is returned when a new API key is invalid.
+	ErrInvalidAPIKey = errors.New("invalid api key")
+
+	// ErrAPIKeyRejected is returned when an API key is unknown or
+	// expired.
+	ErrAPIKeyRejected = errors.New("api key rejected")
+)
+
+// hashAPIKey returns the hash an API key is stored as.  Keys carry 256 bits of
+// entropy, so a plain SHA-256 hash is not subject to brute force.
+func hashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// CreateAPIKey creates an API key with the passed name and scope that expires
+// at the passed UNIX timestamp, or never when it is zero.  The returned key is
+// not stored and can not be recovered.
+func (s *Server) CreateAPIKey(ctx context.Context, name, scope string, expiresAt int64) (*types.CreateAPIKeyResult, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: empty name", ErrInvalidAPIKey)
+	}
+	switch scope {
+	case database.APIKeyScopeRead, database.APIKeyScopeSync,
+		database.APIKeyScopeAdmin:
+	default:
+		return nil, fmt.Errorf("%w: scope %q", ErrInvalidAPIKey, scope)
+	}
+	now := time.Now().Unix()
+	if expiresAt != 0 && expiresAt <= now {
+		return nil, fmt.Errorf("%w: expiry in the past", ErrInvalidAPIKey)
+	}
+
+	var id [8]byte
+	var secret [32]byte
+	for _, b := range [][]byte{id[:], secret[:]} {
+		_, err := rand.Read(b)
+		if err != nil {
+			return nil, err
+		}
+	}
+	key := apiKeyPrefix + hex.EncodeToString(secret[:])
+
+	dbKey := &database.APIKey{
+		ID:        hex.EncodeToString(id[:]),
+		Name:      name,
+		Hash:      hashAPIKey(key),
+		Scope:     scope,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}
+	err := s.DB.NewAPIKey(ctx, dbKey)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Created %v API key %v (%v)", scope, dbKey.ID, name)
+
+	return &types.CreateAPIKeyResult{
+		ID:        dbKey.ID,
+		Name:      name,
+		Key:       key,
+		Scope:     scope,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// RevokeAPIKey removes the API key with the passed ID.  The key is rejected
+// from then on.
+func (s *Server) RevokeAPIKey(ctx context.Context, id string) error {
+	err := s.DB.DeleteAPIKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	log.Infof("Revoked API key %v", id)
+	return nil
+}
+
+// ListAPIKeys returns all API keys without the keys themselves.
+func (r *Reader) ListAPIKeys(ctx context.Context) (*types.ListAPIKeysResult, error) {
+	dbKeys, err := r.db.APIKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]types.APIKeyInformation, 0, len(dbKeys))
+	for _, key := range dbKeys {
+		keys = append(keys, types.APIKeyInformation{
+			ID:         key.ID,
+			Name:       key.Name,
+			Scope:      key.Scope,
+			CreatedAt:  key.CreatedAt,
+			ExpiresAt:  key.ExpiresAt,
+			LastUsedAt: key.LastUsedAt,
+		})
+	}
+	return &types.ListAPIKeysResult{
+		Keys: keys,
+	}, nil
+}
+
+// AuthenticateAPIKey returns the stored API key matching the passed key and
+// records its use.  ErrAPIKeyRejected is returned when the key is unknown or
+// expired.
+func (s *Server) AuthenticateAPIKey(ctx context.Context, key string) (*database.APIKey, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, ErrAPIKeyRejected
+	}
+	dbKey, err := s.DB.APIKeyByHash(ctx, hashAPIKey(key))
+	if errors.Is(err, database.ErrAPIKeyNotFound) {
+		return nil, ErrAPIKeyRejected
+	} else if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if dbKey.ExpiresAt != 0 && now.Unix() >= dbKey.ExpiresAt {
+		return nil, ErrAPIKeyRejected
+	}
+	if now.Sub(time.Unix(dbKey.LastUsedAt, 0)) >= apiKeyUsedInterval {
+		err := s.DB.APIKeyUsed(ctx, dbKey.ID, now.Unix())
+		if err != nil {
+			log.Warnf("Unable to record use of API key %v: %v",
+				dbKey.ID, err)
+		}
+		dbKey.LastUsedAt = now.Unix()
+	}
+	return dbKey, nil
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/decred/github-tracker/database"
+)
+
+// apiKeyDB stores API keys in memory.  All other database methods panic.
+type apiKeyDB struct {
+	database.Database
+	keys map[string]*database.APIKey // By hash
+}
+
+func (db *apiKeyDB) NewAPIKey(ctx context.Context, key *database.APIKey) error {
+	k := *key
+	db.keys[key.Hash] = &k
+	return nil
+}
+
+func (db *apiKeyDB) APIKeyByHash(ctx context.Context, hash string) (*database.APIKey, error) {
+	key, ok := db.keys[hash]
+	if !ok {
+		return nil, database.ErrAPIKeyNotFound
+	}
+	k := *key
+	return &k, nil
+}
+
+func (db *apiKeyDB) APIKeyUsed(ctx context.Context, id string, usedAt int64) error {
+	for _, key := range db.keys {
+		if key.ID == id {
+			key.LastUsedAt = usedAt
+		}
+	}
+	return nil
+}
+
+func (db *apiKeyDB) DeleteAPIKey(ctx context.Context, id string) error {
+	for hash, key := range db.keys {
+		if key.ID == id {
+			delete(db.keys, hash)
+		}
+	}
+	return nil
+}
+
+func TestHashAPIKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{
+			key:  "",
+			want: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		},
+		{
+			key:  "abc",
+			want: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		},
+	}
+	for _, test := range tests {
+		got := hashAPIKey(test.key)
+		if got != test.want {
+			t.Errorf("hashAPIKey(%q): got %v, want %v", test.key, got,
+				test.want)
+		}
+	}
+}
+
+func TestCreateAPIKeyInvalid(t *testing.T) {
+	s := &Server{DB: &apiKeyDB{keys: make(map[string]*database.APIKey)}}
+	tests := []struct {
+		name      string
+		keyName   string
+		scope     string
+		expiresAt int64
+	}{
+		{
+			name:    "empty name",
+			keyName: " ",
+			scope:   database.APIKeyScopeRead,
+		},
+		{
+			name:    "scope",
+			keyName: "ci",
+			scope:   "write",
+		},
+		{
+			name:      "expired",
+			keyName:   "ci",
+			scope:     database.APIKeyScopeRead,
+			expiresAt: time.Now().Add(-time.Hour).Unix(),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := s.CreateAPIKey(context.Background(), test.keyName,
+				test.scope, test.expiresAt)
+			if !errors.Is(err, ErrInvalidAPIKey) {
+				t.Fatalf("got %v, want %v", err, ErrInvalidAPIKey)
+			}
+		})
+	}
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	ctx := context.Background()
+	db := &apiKeyDB{keys: make(map[string]*database.APIKey)}
+	s := &Server{DB: db}
+
+	created, err := s.CreateAPIKey(ctx, "ci", database.APIKeyScopeSync, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(created.Key, apiKeyPrefix) {
+		t.Fatalf("key %q does not have prefix %q", created.Key,
+			apiKeyPrefix)
+	}
+	if _, ok := db.keys[hashAPIKey(created.Key)]; !ok {
+		t.Fatal("key is not stored by its hash")
+	}
+
+	expired := apiKeyPrefix + "expired"
+	db.keys[hashAPIKey(expired)] = &database.APIKey{
+		ID:        "expired",
+		Hash:      hashAPIKey(expired),
+		Scope:     database.APIKeyScopeAdmin,
+		ExpiresAt: time.Now().Add(-time.Second).Unix(),
+	}
+	unprefixed := "unprefixed"
+	db.keys[hashAPIKey(unprefixed)] = &database.APIKey{
+		ID:    "unprefixed",
+		Hash:  hashAPIKey(unprefixed),
+		Scope: database.APIKeyScopeAdmin,
+	}
+
+	tests := []struct {
+		name  string
+		key   string
+		scope string
+		err   error
+	}{
+		{
+			name:  "valid",
+			key:   created.Key,
+			scope: database.APIKeyScopeSync,
+		},
+		{
+			name: "unknown",
+			key:  apiKeyPrefix + "unknown",
+			err:  ErrAPIKeyRejected,
+		},
+		{
+			name: "expired",
+			key:  expired,
+			err:  ErrAPIKeyRejected,
+		},
+		{
+			name: "prefix",
+			key:  unprefixed,
+			err:  ErrAPIKeyRejected,
+		},
+		{
+			name: "hash",
+			key:  hashAPIKey(created.Key),
+			err:  ErrAPIKeyRejected,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			key, err := s.AuthenticateAPIKey(ctx, test.key)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("got error %v, want %v", err, test.err)
+			}
+			if err != nil {
+				return
+			}
+			if key.Scope != test.scope {
+				t.Fatalf("got scope %v, want %v", key.Scope, test.scope)
+			}
+			if key.LastUsedAt == 0 {
+				t.Fatal("use of key not recorded")
+			}
+		})
+	}
+
+	err = s.RevokeAPIKey(ctx, created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.AuthenticateAPIKey(ctx, created.Key)
+	if !errors.Is(err, ErrAPIKeyRejected) {
+		t.Fatalf("revoked key: got %v, want %v", err, ErrAPIKeyRejected)
+	}
+}
@@ -5,6 +5,7 @@
 package server
 
 import (
+	"strings"
 	"time"
 
 	"github.com/decred/github-tracker/api"
@@ -12,30 +13,126 @@ import (
 	"github.com/decred/github-tracker/jsonrpc/types"
 )
 
+// ghostLogin is the login GitHub attributes the content of deleted accounts
+// to.  Many unrelated deleted accounts map to it, so it is not recorded as a
+// user identity.
+const ghostLogin = "ghost"
+
+// convertAPIUserToDbUser converts a GitHub account to a database user.  False
+// is returned for accounts without a stable identity such as the ghost user and
+// commit authors that are not linked to any account.
+func convertAPIUserToDbUser(apiUser api.ApiUser) (database.User, bool) {
+	if apiUser.ID == 0 || apiUser.Login == "" || apiUser.Login == ghostLogin {
+		return database.User{}, false
+	}
+	return database.User{
+		ID:     apiUser.ID,
+		NodeID: apiUser.NodeID,
+		Login:  apiUser.Login,
+		Type:   apiUser.Type,
+	}, true
+}
+
+// userID returns the numeric ID of the passed account, or zero when the account
+// has no stable identity.
+func userID(apiUser api.ApiUser) int64 {
+	if _, ok := convertAPIUserToDbUser(apiUser); !ok {
+		return 0
+	}
+	return apiUser.ID
+}
+
+// convertAPIUsersToDbUsers returns the unique set of users with a stable
+// identity among the passed accounts.
+func convertAPIUsersToDbUsers(apiUsers ...api.ApiUser) []database.User {
+	seen := make(map[int64]struct{}, len(apiUsers))
+	dbUsers := make([]database.User, 0, len(apiUsers))
+	for _, apiUser := range apiUsers {
+		dbUser, ok := convertAPIUserToDbUser(apiUser)
+		if !ok {
+			continue
+		}
+		if _, ok := seen[dbUser.ID]; ok {
+			continue
+		}
+		seen[dbUser.ID] = struct{}{}
+		dbUsers = append(dbUsers, dbUser)
+	}
+	return dbUsers
+}
+
+func convertAPIRepositoryToDbRepository(repo api.ApiRepository) *database.Repository {
+	return &database.Repository{
+		ID:             repo.ID,
+		OrganizationID: repo.Owner.ID,
+		Organization:   repo.Owner.Login,
+		Name:           repo.Name,
+		FullName:       repo.FullName,
+		DefaultBranch:  repo.DefaultBranch,
+		Fork:           repo.Fork,
+		Archived:       repo.Archived,
+		Private:        repo.Private,
+	}
+}
+
 func convertAPIPullRequestToDbPullRequest(apiPR *api.ApiPullRequest, repo api.ApiRepository, org string) (*database.PullRequest, error) {
 	dbPR := &database.PullRequest{
+		RepoID:       repo.ID,
 		Repo:         repo.Name,
 		Organization: org,
 		User:         apiPR.User.Login,
+		UserID:       userID(apiPR.User),
 		URL:          apiPR.URL,
 		Number:       apiPR.Number,
+		Title:        apiPR.Title,
+		Body:         apiPR.Body,
+		BaseRef:      apiPR.Base.Ref,
+		HeadRef:      apiPR.Head.Ref,
+		Draft:        apiPR.Draft,
+		Merged:       apiPR.Merged,
+		MergedBy:     apiPR.MergedBy.Login,
 		State:        apiPR.State,
 		Additions:    apiPR.Additions,
 		Deletions:    apiPR.Deletions,
 	}
-	if apiPR.MergedAt != "" {
-		mergedAt, err := time.Parse(time.RFC3339, apiPR.MergedAt)
-		if err != nil {
-			return nil, err
-		}
-		dbPR.MergedAt = mergedAt.Unix()
+
+	// The head repository is missing when it has been deleted, in which
+	// case the PR necessarily came from a fork.
+	if apiPR.Head.Repo != nil {
+		dbPR.HeadRepo = apiPR.Head.Repo.FullName
+		dbPR.Fork = apiPR.Head.Repo.ID != repo.ID
+	} else {
+		dbPR.Fork = true
+	}
+	if apiPR.Milestone != nil {
+		dbPR.Milestone = apiPR.Milestone.Title
 	}
-	if apiPR.UpdatedAt != "" {
-		updatedAt, err := time.Parse(time.RFC3339, apiPR.UpdatedAt)
+	for _, label := range apiPR.Labels {
+		dbPR.Labels = append(dbPR.Labels, label.Name)
+	}
+	for _, reviewer := range apiPR.RequestedReviewers {
+		dbPR.RequestedReviewers = append(dbPR.RequestedReviewers,
+			reviewer.Login)
+	}
+
+	timestamps := []struct {
+		value string
+		dest  *int64
+	}{
+		{apiPR.CreatedAt, &dbPR.CreatedAt},
+		{apiPR.UpdatedAt, &dbPR.UpdatedAt},
+		{apiPR.ClosedAt, &dbPR.ClosedAt},
+		{apiPR.MergedAt, &dbPR.MergedAt},
+	}
+	for _, ts := range timestamps {
+		if ts.value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, ts.value)
 		if err != nil {
 			return nil, err
 		}
-		dbPR.UpdatedAt = updatedAt.Unix()
+		*ts.dest = t.Unix()
 	}
 	return dbPR, nil
 }
@@ -51,21 +148,28 @@ func convertAPICommitsToDbCommits(apiCommits []api.ApiPullRequestCommit) []datab
 
 func convertAPICommitToDbCommit(apiCommit api.ApiPullRequestCommit) database.Commit {
 	dbCommit := database.Commit{
-		SHA:       apiCommit.SHA,
-		URL:       apiCommit.URL,
-		Message:   apiCommit.Commit.Message,
-		Author:    apiCommit.Author.Login,
-		Committer: apiCommit.Committer.Login,
-		Additions: apiCommit.Stats.Additions,
-		Deletions: apiCommit.Stats.Deletions,
+		SHA:         apiCommit.SHA,
+		URL:         apiCommit.URL,
+		Message:     apiCommit.Commit.Message,
+		Author:      apiCommit.Author.Login,
+		AuthorID:    userID(apiCommit.Author),
+		AuthorEmail: strings.ToLower(apiCommit.Commit.Author.Email),
+		Committer:   apiCommit.Committer.Login,
+		CommitterID: userID(apiCommit.Committer),
+		Additions:   apiCommit.Stats.Additions,
+		Deletions:   apiCommit.Stats.Deletions,
+	}
+	if authoredAt := parseTime(apiCommit.Commit.Author.Date); !authoredAt.IsZero() {
+		dbCommit.AuthoredAt = authoredAt.Unix()
 	}
 	return dbCommit
 }
 
-func convertAPIReviewsToDbReviews(apiReviews []api.ApiPullRequestReview, repo string, prNumber int) []database.PullRequestReview {
+func convertAPIReviewsToDbReviews(apiReviews []api.ApiPullRequestReview, prURL, repo string, prNumber int) []database.PullRequestReview {
 	dbReviews := make([]database.PullRequestReview, 0, len(apiReviews))
 	for _, review := range apiReviews {
 		dbReview := convertAPIReviewToDbReview(review)
+		dbReview.PullRequestURL = prURL
 		dbReview.Repo = repo
 		dbReview.Number = prNumber
 		dbReviews = append(dbReviews, dbReview)
@@ -77,6 +181,7 @@ func convertAPIReviewToDbReview(apiReview api.ApiPullRequestReview) database.Pul
 	dbReview := database.PullRequestReview{
 		ID:          apiReview.ID,
 		Author:      apiReview.User.Login,
+		AuthorID:    userID(apiReview.User),
 		State:       apiReview.State,
 		SubmittedAt: parseTime(apiReview.SubmittedAt).Unix(),
 		CommitID:    apiReview.CommitID,
@@ -84,78 +189,92 @@ func convertAPIReviewToDbReview(apiReview api.ApiPullRequestReview) database.Pul
 	return dbReview
 }
 
+// convertAPIPullRequestUsers returns the users referenced by a pull request,
+// its commits and its reviews.
+func convertAPIPullRequestUsers(apiPR *api.ApiPullRequest, apiCommits []api.ApiPullRequestCommit, apiReviews []api.ApiPullRequestReview) []database.User {
+	apiUsers := make([]api.ApiUser, 0, 2+len(apiPR.RequestedReviewers)+
+		2*len(apiCommits)+len(apiReviews))
+	apiUsers = append(apiUsers, apiPR.User, apiPR.MergedBy)
+	apiUsers = append(apiUsers, apiPR.RequestedReviewers...)
+	for _, commit := range apiCommits {
+		apiUsers = append(apiUsers, commit.Author, commit.Committer)
+	}
+	for _, review := range apiReviews {
+		apiUsers = append(apiUsers, review.User)
+	}
+	return convertAPIUsersToDbUsers(apiUsers...)
+}
+
 func convertDBPullRequestsToPullRequests(dbPRs []*database.PullRequest) []types.PullRequestInformation {
 	prInfo := make([]types.PullRequestInformation, 0, len(dbPRs))
 
 	for _, dbPR := range dbPRs {
-		pr := types.PullRequestInformation{
-			Repository: dbPR.Repo,
-			Additions:  int64(dbPR.Additions),
-			Deletions:  int64(dbPR.Deletions),
-			Date:       time.Unix(dbPR.MergedAt, 0).Format(time.RFC1123),
-			Number:     dbPR.Number,
-		}
+		pr := convertDBPullRequestToPullRequest(dbPR)
+		pr.Author = dbPR.User
+		pr.Date = time.Unix(dbPR.MergedAt, 0).Format(time.RFC1123)
 		prInfo = append(prInfo, pr)
 	}
 	return prInfo
 }
 
-func convertPRsandReviewsToUserInformation(prs []*database.PullRequest, reviews []database.PullRequestReview) *types.UserInformationResult {
-	repoStats := make([]types.RepositoryInformation, 0, 1048) // PNOOMA
+// convertDBPullRequestToPullRequest converts the stored metadata of a pull
+// request.  The date is left for the caller to format.
+func convertDBPullRequestToPullRequest(dbPR *database.PullRequest) types.PullRequestInformation {
+	return types.PullRequestInformation{
+		Repository:         dbPR.Repo,
+		URL:                dbPR.URL,
+		Number:             dbPR.Number,
+		Title:              dbPR.Title,
+		Body:               dbPR.Body,
+		Labels:             dbPR.Labels,
+		BaseRef:            dbPR.BaseRef,
+		HeadRef:            dbPR.HeadRef,
+		HeadRepository:     dbPR.HeadRepo,
+		Fork:               dbPR.Fork,
+		Draft:              dbPR.Draft,
+		CreatedAt:          dbPR.CreatedAt,
+		RequestedReviewers: dbPR.RequestedReviewers,
+		Milestone:          dbPR.Milestone,
+		Additions:          int64(dbPR.Additions),
+		Deletions:          int64(dbPR.Deletions),
+		State:              dbPR.State,
+	}
+}
+
+func convertPRsReviewsAndCommitsToUserInformation(prs []*database.PullRequest, reviews []database.PullRequestReview, commits []database.Commit) *types.UserInformationResult {
+	repoStats := make([]types.RepositoryInformation, 0, 16)
+	repoIndex := make(map[string]int, 16)
+	repoStat := func(repo string) *types.RepositoryInformation {
+		i, ok := repoIndex[repo]
+		if !ok {
+			i = len(repoStats)
+			repoIndex[repo] = i
+			repoStats = append(repoStats, types.RepositoryInformation{
+				Repository: repo,
+			})
+		}
+		return &repoStats[i]
+	}
+
 	userInfo := &types.UserInformationResult{}
 	prInfo := make([]types.PullRequestInformation, 0, len(prs))
 	reviewInfo := make([]types.ReviewInformation, 0, len(reviews))
+	commitInfo := make([]types.CommitInformation, 0, len(commits))
 	for _, pr := range prs {
-		repoFound := false
-		for i, repoStat := range repoStats {
-			if repoStat.Repository == pr.Repo {
-				repoFound = true
-				repoStat.PRs = append(repoStat.PRs, pr.URL)
-				repoStat.MergeAdditions += int64(pr.Additions)
-				repoStat.MergeDeletions += int64(pr.Deletions)
-				repoStats[i] = repoStat
-				break
-			}
-		}
-		if !repoFound {
-			repoStat := types.RepositoryInformation{
-				PRs:            []string{pr.URL},
-				Repository:     pr.Repo,
-				MergeAdditions: int64(pr.Additions),
-				MergeDeletions: int64(pr.Deletions),
-			}
-			repoStats = append(repoStats, repoStat)
-		}
-		prInfo = append(prInfo, types.PullRequestInformation{
-			Repository: pr.Repo,
-			URL:        pr.URL,
-			Number:     pr.Number,
-			Additions:  int64(pr.Additions),
-			Deletions:  int64(pr.Deletions),
-			Date:       time.Unix(pr.MergedAt, 0).String(),
-			State:      pr.State,
-		})
+		stat := repoStat(pr.Repo)
+		stat.PRs = append(stat.PRs, pr.URL)
+		stat.MergeAdditions += int64(pr.Additions)
+		stat.MergeDeletions += int64(pr.Deletions)
 
+		info := convertDBPullRequestToPullRequest(pr)
+		info.Date = time.Unix(pr.MergedAt, 0).String()
+		prInfo = append(prInfo, info)
 	}
 	for _, review := range reviews {
-		repoFound := false
-		for i, repoStat := range repoStats {
-			if repoStat.Repository == review.Repo {
-				repoFound = true
-				repoStat.ReviewAdditions += int64(review.Additions)
-				repoStat.ReviewDeletions += int64(review.Deletions)
-				repoStats[i] = repoStat
-				break
-			}
-		}
-		if !repoFound {
-			repoStat := types.RepositoryInformation{
-				Repository:      review.Repo,
-				ReviewAdditions: int64(review.Additions),
-				ReviewDeletions: int64(review.Deletions),
-			}
-			repoStats = append(repoStats, repoStat)
-		}
+		stat := repoStat(review.Repo)
+		stat.ReviewAdditions += int64(review.Additions)
+		stat.ReviewDeletions += int64(review.Deletions)
+
 		reviewInfo = append(reviewInfo, types.ReviewInformation{
 			State:      review.State,
 			Number:     review.Number,
@@ -164,9 +283,40 @@ func convertPRsandReviewsToUserInformation(prs []*database.PullRequest, reviews
 			Deletions:  review.Deletions,
 		})
 	}
+	for _, commit := range commits {
+		stat := repoStat(commit.Repo)
+		stat.CommitAdditions += int64(commit.Additions)
+		stat.CommitDeletions += int64(commit.Deletions)
+
+		commitInfo = append(commitInfo, types.CommitInformation{
+			Repository:  commit.Repo,
+			SHA:         commit.SHA,
+			URL:         commit.URL,
+			PullRequest: commit.PullRequestURL,
+			Author:      commit.Author,
+			AuthorEmail: commit.AuthorEmail,
+			Additions:   commit.Additions,
+			Deletions:   commit.Deletions,
+			Date:        time.Unix(commit.AuthoredAt, 0).String(),
+		})
+	}
 
 	userInfo.RepoDetails = repoStats
 	userInfo.PRs = prInfo
 	userInfo.Reviews = reviewInfo
+	userInfo.Commits = commitInfo
 	return userInfo
 }
+
+func convertDBChangeToChange(change database.Change) types.ChangeInformation {
+	return types.ChangeInformation{
+		PullRequest: change.PullRequestURL,
+		Record:      change.Record,
+		RecordID:    change.RecordID,
+		Action:      change.Action,
+		Field:       change.Field,
+		Old:         change.Old,
+		New:         change.New,
+		Date:        time.Unix(change.ChangedAt, 0).String(),
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/decred/github-tracker/api"
+	"github.com/decred/github-tracker/database"
+	"github.com/decred/github-tracker/jsonrpc/types"
+)
+
+// pseudonymPrefix is the prefix of the logins that replace pseudonymized
+// users.
+const pseudonymPrefix = "pseudonym-"
+
+// userIdentity resolves the passed login or contributor name to the identity
+// whose data is erased.  ErrInvalidUser is returned when the user is empty.
+func (s *Server) userIdentity(ctx context.Context, user string) (*database.UserIdentity, error) {
+	if strings.TrimSpace(user) == "" {
+		return nil, fmt.Errorf("%w: empty login or contributor",
+			ErrInvalidUser)
+	}
+	id, err := s.contributorIdentities(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return &database.UserIdentity{
+		Contributor: id.contributor,
+		Logins:      id.logins,
+		Emails:      id.emails,
+		UserIDs:     id.userIDs,
+	}, nil
+}
+
+// eraseArchive removes or pseudonymizes the passed identity in all archived
+// responses and in the responses archived from now on.
+func (s *Server) eraseArchive(identity *database.UserIdentity, pseudonym string) error {
+	if s.archive == nil {
+		return nil
+	}
+	n, err := s.archive.Erase(api.Erasure{
+		UserIDs:   identity.UserIDs,
+		Logins:    identity.Logins,
+		Emails:    identity.Emails,
+		Pseudonym: pseudonym,
+	})
+	if err != nil {
+		return fmt.Errorf("erase archive: %v", err)
+	}
+	log.Infof("Rewrote %d archived responses", n)
+	return nil
+}
+
+// LoadErasures scrubs the users erased in the database from the responses
+// archived from now on.
+func (s *Server) LoadErasures(ctx context.Context) error {
+	if s.archive == nil {
+		return nil
+	}
+	erased, err := s.DB.ErasedIdentities(ctx)
+	if err != nil {
+		return err
+	}
+	s.archive.AddErasures(archiveErasures(erased)...)
+	return nil
+}
+
+// archiveErasures groups the passed erased identities by their pseudonym.
+func archiveErasures(erased []database.ErasedIdentity) []api.Erasure {
+	byPseudonym := make(map[string]*api.Erasure)
+	var erasures []*api.Erasure
+	for _, e := range erased {
+		erasure, ok := byPseudonym[e.Pseudonym]
+		if !ok {
+			erasure = &api.Erasure{Pseudonym: e.Pseudonym}
+			byPseudonym[e.Pseudonym] = erasure
+			erasures = append(erasures, erasure)
+		}
+		switch e.Type {
+		case database.ErasedUserID:
+			id, err := strconv.ParseInt(e.Value, 10, 64)
+			if err == nil {
+				erasure.UserIDs = append(erasure.UserIDs, id)
+			}
+		case database.ErasedLogin:
+			erasure.Logins = append(erasure.Logins, e.Value)
+		case database.ErasedEmail:
+			erasure.Emails = append(erasure.Emails, e.Value)
+		}
+	}
+
+	result := make([]api.Erasure, 0, len(erasures))
+	for _, erasure := range erasures {
+		result = append(result, *erasure)
+	}
+	return result
+}
+
+// PurgeUser removes everything tied to the passed login or contributor:
+// authored pull requests, reviews, commits, aliases, rollups, snapshots and
+// archived responses.  The erasure is recorded so that the user is not stored
+// again by later syncs, imports and reindexing.
+func (s *Server) PurgeUser(ctx context.Context, user string) (*types.PurgeUserResult, error) {
+	identity, err := s.userIdentity(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.DB.PurgeUser(ctx, *identity)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Purged user %v (logins %v)", user, identity.Logins)
+
+	err = s.eraseArchive(identity, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.PurgeUserResult{
+		User:   user,
+		Logins: identity.Logins,
+		Emails: identity.Emails,
+	}, nil
+}
+
+// PseudonymizeUser replaces the passed login or contributor with a random
+// pseudonym in all stored data and archived responses, keeping its pull
+// requests, reviews and commits while removing its emails, aliases and
+// snapshots.  The erasure is recorded so that the user is replaced by the same
+// pseudonym when it is stored again.
+func (s *Server) PseudonymizeUser(ctx context.Context, user string) (*types.PseudonymizeUserResult, error) {
+	identity, err := s.userIdentity(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	var b [8]byte
+	_, err = rand.Read(b[:])
+	if err != nil {
+		return nil, err
+	}
+	pseudonym := pseudonymPrefix + hex.EncodeToString(b[:])
+
+	err = s.DB.PseudonymizeUser(ctx, *identity, pseudonym)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Pseudonymized user %v (logins %v) as %v", user,
+		identity.Logins, pseudonym)
+
+	err = s.eraseArchive(identity, pseudonym)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.PseudonymizeUserResult{
+		User:      user,
+		Pseudonym: pseudonym,
+		Logins:    identity.Logins,
+	}, nil
+}
+
+// ApplyRetention drops the bodies of the messages of commits authored more
+// than CommitRetention months ago from the database and the archived
+// responses, so that reindexing does not restore them.  Nothing is dropped
+// when CommitRetention is not positive.
+func (s *Server) ApplyRetention(ctx context.Context) error {
+	if s.CommitRetention <= 0 {
+		return nil
+	}
+
+	before := time.Now().AddDate(0, -s.CommitRetention, 0)
+	n, err := s.DB.PurgeCommitMessages(ctx, before.Unix())
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Infof("Dropped message bodies of %d commits authored before %v",
+			n, before.Format("2006-01-02"))
+	}
+
+	if s.archive == nil {
+		return nil
+	}
+	rewritten, err := s.archive.TrimCommitMessages(before)
+	if err != nil {
+		return fmt.Errorf("trim archive: %v", err)
+	}
+	if rewritten > 0 {
+		log.Infof("Rewrote %d archived responses", rewritten)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	repositorySyncSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "githubtracker",
+		Subsystem: "sync",
+		Name:      "repository_duration_seconds",
+		Help:      "Time taken to sync the pull requests of a repository.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+	}, []string{"organization", "repository"})
+
+	recordsWritten = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "githubtracker",
+		Subsystem: "sync",
+		Name:      "records_written_total",
+		Help:      "Pull requests, reviews and commits written by syncs.",
+	}, []string{"record"})
+)
+
+// RegisterMetrics registers the Prometheus collectors of the package with r.
+func RegisterMetrics(r prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{repositorySyncSeconds, recordsWritten} {
+		if err := r.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
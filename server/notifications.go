@@ -0,0 +1,85 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"sync"
+)
+
+// notificationQueueSize is the number of notifications that are queued for a
+// subscription before further notifications are dropped.  This keeps a slow
+// client from stalling a sync.
+const notificationQueueSize = 64
+
+// Subscription receives the notifications published while syncing.  C carries
+// *types.SyncProgressNtfn and *types.NewMergesNtfn values and is closed when
+// the subscription is closed.
+type Subscription struct {
+	C <-chan interface{}
+
+	c chan interface{}
+	n *notifier
+}
+
+// Close stops the delivery of notifications to the subscription.
+func (sub *Subscription) Close() {
+	sub.n.mtx.Lock()
+	defer sub.n.mtx.Unlock()
+
+	if _, ok := sub.n.subscriptions[sub]; !ok {
+		return
+	}
+	delete(sub.n.subscriptions, sub)
+	close(sub.c)
+}
+
+// notifier publishes notifications to all subscriptions.
+type notifier struct {
+	mtx           sync.Mutex
+	subscriptions map[*Subscription]struct{}
+}
+
+// newNotifier returns a notifier without any subscriptions.
+func newNotifier() *notifier {
+	return &notifier{
+		subscriptions: make(map[*Subscription]struct{}),
+	}
+}
+
+// publish queues the notification for every subscription.  Nothing is
+// published by a nil notifier.
+func (n *notifier) publish(ntfn interface{}) {
+	if n == nil {
+		return
+	}
+
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	for sub := range n.subscriptions {
+		select {
+		case sub.c <- ntfn:
+		default:
+			log.Warnf("Dropping %T for slow subscriber", ntfn)
+		}
+	}
+}
+
+// Subscribe returns a subscription to the notifications published while
+// syncing.  The subscription must be closed once it is no longer used.
+func (s *Server) Subscribe() *Subscription {
+	c := make(chan interface{}, notificationQueueSize)
+	sub := &Subscription{
+		C: c,
+		c: c,
+		n: s.ntfns,
+	}
+
+	s.ntfns.mtx.Lock()
+	s.ntfns.subscriptions[sub] = struct{}{}
+	s.ntfns.mtx.Unlock()
+
+	return sub
+}
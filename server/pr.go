@@ -5,6 +5,7 @@
 package server
 
 import (
+	"sync"
 	"time"
 
 	"github.com/decred/github-tracker/api"
@@ -12,7 +13,25 @@ import (
 )
 
 type Server struct {
-	tc *api.Client
+	*Reader
+
+	tc      *api.Client
+	archive *api.Archive // Archive of raw API responses, may be nil
+	ntfns   *notifier    // Sync notifications, nil when not published
+	started time.Time    // Time the server was created
+
+	// syncs tracks the running syncs.  stopSyncs is closed once syncs are
+	// stopped, after which no new syncs are started.
+	syncMtx      sync.Mutex
+	syncs        sync.WaitGroup
+	syncsStopped bool
+	stopSyncs    chan struct{}
+
+	// CommitRetention is the number of months after which the bodies of
+	// commit messages are dropped.  Messages are kept forever when it is
+	// not positive.
+	CommitRetention int
+
 	// Following entries are use only during cmswww mode
 	DB database.Database
 }
@@ -23,11 +42,20 @@ type S struct {
 	Total     int
 }
 
-func NewServer(token string) (*Server, error) {
-	tc := api.NewClient(token)
+// NewServer returns a server that fetches data from GitHub using the passed
+// token and stores it in the passed database.  All fetched responses are
+// archived when an archive is passed.
+func NewServer(token string, archive *api.Archive, db database.Database) (*Server, error) {
+	tc := api.NewClient(token, archive)
 
 	return &Server{
-		tc: tc,
+		Reader:    NewReader(db),
+		tc:        tc,
+		archive:   archive,
+		ntfns:     newNotifier(),
+		started:   time.Now(),
+		stopSyncs: make(chan struct{}),
+		DB:        db,
 	}, nil
 }
 
@@ -0,0 +1,98 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/decred/github-tracker/database"
+	"github.com/decred/github-tracker/jsonrpc/types"
+)
+
+// ErrInvalidFilter is returned when a pull request filter cannot be converted
+// to a database query.
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// convertFilterToQuery converts the filter of a listpullrequests request into
+// a database query.
+func convertFilterToQuery(filter types.PullRequestFilter, cursor string, limit int) (*database.PullRequestQuery, error) {
+	q := &database.PullRequestQuery{
+		Organization:  filter.Org,
+		Repo:          filter.Repo,
+		Author:        filter.Author,
+		State:         filter.State,
+		Label:         filter.Label,
+		MergedAfter:   filter.MergedAfter,
+		MergedBefore:  filter.MergedBefore,
+		ClosedAfter:   filter.ClosedAfter,
+		ClosedBefore:  filter.ClosedBefore,
+		UpdatedAfter:  filter.UpdatedAfter,
+		UpdatedBefore: filter.UpdatedBefore,
+		Cursor:        cursor,
+		Limit:         limit,
+	}
+
+	switch filter.Sort {
+	case "", "updated":
+		q.SortBy = database.SortByUpdated
+	case "created":
+		q.SortBy = database.SortByCreated
+	case "merged":
+		q.SortBy = database.SortByMerged
+	case "closed":
+		q.SortBy = database.SortByClosed
+	case "number":
+		q.SortBy = database.SortByNumber
+	default:
+		return nil, fmt.Errorf("%w: sort %q", ErrInvalidFilter, filter.Sort)
+	}
+
+	switch filter.Order {
+	case "", "desc":
+	case "asc":
+		q.Ascending = true
+	default:
+		return nil, fmt.Errorf("%w: order %q", ErrInvalidFilter, filter.Order)
+	}
+
+	return q, nil
+}
+
+// ListPullRequests returns a page of pull requests that match the passed
+// filter.
+func (r *Reader) ListPullRequests(ctx context.Context, filter types.PullRequestFilter, cursor string, limit int) (*types.ListPullRequestsResult, error) {
+	q, err := convertFilterToQuery(filter, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+	dbPRs, next, err := r.db.PullRequests(ctx, *q)
+	if err != nil {
+		return nil, err
+	}
+	return &types.ListPullRequestsResult{
+		PullRequests: convertDBPullRequestsToPullRequests(dbPRs),
+		NextCursor:   next,
+	}, nil
+}
+
+// PullRequestHistory returns the changes that sync applied to the pull request
+// with the passed URL along with its reviews and commits.
+func (r *Reader) PullRequestHistory(ctx context.Context, url string) (*types.PRHistoryResult, error) {
+	dbChanges, err := r.db.PullRequestHistory(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]types.ChangeInformation, 0, len(dbChanges))
+	for _, change := range dbChanges {
+		changes = append(changes, convertDBChangeToChange(change))
+	}
+	return &types.PRHistoryResult{
+		URL:     url,
+		Changes: changes,
+	}, nil
+}
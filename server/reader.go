@@ -0,0 +1,23 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"github.com/decred/github-tracker/database"
+)
+
+// Reader serves the queries that only read from the database, such as user
+// information, summaries and pull request listings.  It is all the JSON-RPC
+// handlers of read only methods are given access to.
+type Reader struct {
+	db database.Reader
+}
+
+// NewReader returns a reader that queries the passed database.
+func NewReader(db database.Reader) *Reader {
+	return &Reader{
+		db: db,
+	}
+}
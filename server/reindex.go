@@ -0,0 +1,49 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/decred/github-tracker/api"
+)
+
+// ErrNoArchive is returned when reindexing is requested without an archive.
+var ErrNoArchive = errors.New("no api archive")
+
+// Reindex rebuilds the database tables holding data fetched from GitHub by
+// replaying the most recent archived API responses of every archived
+// organization.  No network access is performed, which allows fixes to the
+// conversion of API data to be applied to historical data without refetching
+// it.
+func (s *Server) Reindex(ctx context.Context) error {
+	if s.archive == nil {
+		return ErrNoArchive
+	}
+
+	err := s.DB.Build(ctx)
+	if err != nil {
+		return fmt.Errorf("Build: %v", err)
+	}
+
+	replay := &Server{
+		Reader:          s.Reader,
+		tc:              api.NewReplayClient(s.archive),
+		CommitRetention: s.CommitRetention,
+		DB:              s.DB,
+	}
+	for _, org := range s.archive.Organizations() {
+		log.Infof("Reindexing %s", org)
+
+		err := replay.Update(ctx, org)
+		if err != nil {
+			return fmt.Errorf("reindex %s: %v", org, err)
+		}
+	}
+
+	return nil
+}
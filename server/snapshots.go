@@ -0,0 +1,145 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/decred/github-tracker/database"
+	"github.com/decred/github-tracker/jsonrpc/types"
+)
+
+// ErrInvalidMonth is returned when a snapshot of an invalid or unfinished
+// month is requested.
+var ErrInvalidMonth = errors.New("invalid month")
+
+// snapshotHash returns the hex encoded SHA-256 of a snapshot payload.
+func snapshotHash(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// SnapshotMonth freezes the user information of every user that merged a pull
+// request or submitted a review in the passed organization during the passed
+// month.  A single snapshot is taken of each contributor, named after the
+// contributor, or after the current login of users without aliases.
+// Snapshots are immutable, so a month can only be snapshotted once per
+// organization.
+func (s *Server) SnapshotMonth(ctx context.Context, org string, year, month int) (*types.SnapshotMonthResult, error) {
+	if month < 1 || month > 12 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidMonth, month)
+	}
+	startDate, endDate := monthRange(year, month)
+	now := time.Now()
+	if now.Unix() < endDate {
+		return nil, fmt.Errorf("%w: %d-%02d has not ended", ErrInvalidMonth,
+			year, month)
+	}
+
+	users, err := s.DB.AllUsersByDates(ctx, org, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	// The aliased logins of a contributor and the logins of a renamed
+	// account resolve to the same identities, which are only snapshotted
+	// once.
+	snapshotted := make(map[string]struct{}, len(users))
+	snapshots := make([]database.Snapshot, 0, len(users))
+	for _, user := range users {
+		if _, ok := snapshotted[user]; ok || user == "" {
+			continue
+		}
+		id, err := s.contributorIdentities(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		for _, login := range id.logins {
+			snapshotted[login] = struct{}{}
+		}
+
+		name := id.name()
+		userInfo, err := s.userInformation(ctx, org, name, id, year, month)
+		if err != nil {
+			return nil, err
+		}
+		payload, err := json.Marshal(userInfo)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, database.Snapshot{
+			Organization: org,
+			User:         name,
+			Year:         year,
+			Month:        month,
+			Payload:      payload,
+			Hash:         snapshotHash(payload),
+			CreatedAt:    now.Unix(),
+		})
+	}
+	err = s.DB.NewSnapshots(ctx, snapshots)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &types.SnapshotMonthResult{
+		Organization: org,
+		Year:         year,
+		Month:        month,
+		Snapshots:    make([]types.SnapshotInformation, 0, len(snapshots)),
+	}
+	for _, snapshot := range snapshots {
+		result.Snapshots = append(result.Snapshots, types.SnapshotInformation{
+			User: snapshot.User,
+			Hash: snapshot.Hash,
+			Date: time.Unix(snapshot.CreatedAt, 0).String(),
+		})
+	}
+	return result, nil
+}
+
+// UserSnapshot returns the frozen user information of the passed login or
+// contributor for the passed month.  The payload is verified against its
+// stored hash.
+func (r *Reader) UserSnapshot(ctx context.Context, org, user string, year, month int) (*types.UserInformationResult, error) {
+	id, err := r.contributorIdentities(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	// The snapshot is named after the contributor or the login of the
+	// time it was taken, which may since have been aliased or renamed.
+	var snapshot *database.Snapshot
+	for _, name := range append([]string{id.name()}, id.logins...) {
+		snapshot, err = r.db.SnapshotByUser(ctx, org, name, year, month)
+		if err != database.ErrSnapshotNotFound {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if snapshotHash(snapshot.Payload) != snapshot.Hash {
+		return nil, fmt.Errorf("snapshot of %v for %d-%02d does not match "+
+			"its hash", user, year, month)
+	}
+
+	var userInfo types.UserInformationResult
+	err = json.Unmarshal(snapshot.Payload, &userInfo)
+	if err != nil {
+		return nil, err
+	}
+	userInfo.Snapshot = &types.SnapshotInformation{
+		Hash: snapshot.Hash,
+		Date: time.Unix(snapshot.CreatedAt, 0).String(),
+	}
+	return &userInfo, nil
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/decred/github-tracker/jsonrpc/types"
+)
+
+// Status returns the uptime of the server, whether the database is reachable,
+// the last known GitHub rate limit and the time every organization was last
+// synced.
+func (s *Server) Status(ctx context.Context) (*types.StatusResult, error) {
+	rateLimit := s.tc.RateLimitState()
+	status := &types.StatusResult{
+		Uptime: int64(time.Since(s.started) / time.Second),
+		RateLimit: types.RateLimitInformation{
+			Limit:     rateLimit.Limit,
+			Remaining: rateLimit.Remaining,
+			Reset:     rateLimit.Reset,
+		},
+		Organizations: []types.OrganizationSyncStatus{},
+	}
+
+	err := s.DB.Ping(ctx)
+	if err != nil {
+		status.DBError = err.Error()
+		return status, nil
+	}
+	status.DBConnected = true
+
+	orgs, err := s.DB.Organizations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, org := range orgs {
+		status.Organizations = append(status.Organizations,
+			types.OrganizationSyncStatus{
+				Organization: org.Login,
+				LastSync:     org.LastSync,
+			})
+	}
+	return status, nil
+}
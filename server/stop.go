@@ -0,0 +1,51 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrStopping is returned by Update once syncs are stopped.
+var ErrStopping = errors.New("server is stopping")
+
+// beginSync registers a running sync, failing once syncs are stopped.  The
+// sync must be marked done on the syncs wait group when it returns.
+func (s *Server) beginSync() error {
+	s.syncMtx.Lock()
+	defer s.syncMtx.Unlock()
+
+	if s.syncsStopped {
+		return ErrStopping
+	}
+	s.syncs.Add(1)
+	return nil
+}
+
+// StopSyncs stops accepting new syncs and waits up to timeout for the running
+// syncs to checkpoint.  A running sync finishes the repository it is syncing
+// and returns ErrStopping.  It returns false when the timeout expired before
+// every sync returned.
+func (s *Server) StopSyncs(timeout time.Duration) bool {
+	s.syncMtx.Lock()
+	if !s.syncsStopped {
+		s.syncsStopped = true
+		close(s.stopSyncs)
+	}
+	s.syncMtx.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.syncs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/decred/github-tracker/database"
+	"github.com/decred/github-tracker/jsonrpc/types"
+)
+
+// rollupMonth validates the passed month and returns it as YYYYMM.
+func rollupMonth(year, month int) (int, error) {
+	if month < 1 || month > 12 {
+		return 0, fmt.Errorf("%w: %d", ErrInvalidMonth, month)
+	}
+	return year*100 + month, nil
+}
+
+// addRollupStats adds the totals of the passed rollup to the passed stats.
+func addRollupStats(stats *types.SummaryStats, rollup *database.Rollup) {
+	stats.PRs += rollup.PullRequests
+	stats.MergeAdditions += rollup.MergedAdditions
+	stats.MergeDeletions += rollup.MergedDeletions
+	stats.Reviews += rollup.Reviews
+	stats.ReviewAdditions += rollup.ReviewedAdditions
+	stats.ReviewDeletions += rollup.ReviewedDeletions
+	stats.Commits += rollup.Commits
+	stats.CommitAdditions += rollup.CommitAdditions
+	stats.CommitDeletions += rollup.CommitDeletions
+}
+
+// convertRollupsToUserSummaries groups the passed rollups, which must be
+// ordered by login, into per-user summaries.  Rollups whose totals have all
+// dropped back to zero are skipped.
+func convertRollupsToUserSummaries(rollups []database.Rollup) []types.UserSummaryResult {
+	summaries := make([]types.UserSummaryResult, 0, len(rollups))
+	for i := range rollups {
+		rollup := &rollups[i]
+		var repoStats types.SummaryStats
+		addRollupStats(&repoStats, rollup)
+		if repoStats == (types.SummaryStats{}) {
+			continue
+		}
+
+		if len(summaries) == 0 ||
+			summaries[len(summaries)-1].User != rollup.Login {
+			summaries = append(summaries, types.UserSummaryResult{
+				User:         rollup.Login,
+				Repositories: make([]types.RepositorySummary, 0, 1),
+			})
+		}
+		summary := &summaries[len(summaries)-1]
+		addRollupStats(&summary.SummaryStats, rollup)
+		summary.Repositories = append(summary.Repositories,
+			types.RepositorySummary{
+				Repository:   rollup.Repo,
+				SummaryStats: repoStats,
+			})
+	}
+	return summaries
+}
+
+// OrgSummary returns the totals of every user of the passed organization for
+// the passed month.
+func (r *Reader) OrgSummary(ctx context.Context, org string, year, month int) (*types.OrgSummaryResult, error) {
+	m, err := rollupMonth(year, month)
+	if err != nil {
+		return nil, err
+	}
+	rollups, err := r.db.Rollups(ctx, database.RollupQuery{
+		Organization: org,
+		Month:        m,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &types.OrgSummaryResult{
+		Organization: org,
+		Year:         year,
+		Month:        month,
+		Users:        convertRollupsToUserSummaries(rollups),
+	}
+	for i := range rollups {
+		addRollupStats(&result.SummaryStats, &rollups[i])
+	}
+	return result, nil
+}
+
+// UserSummary returns the totals of the passed user in the passed organization
+// for the passed month.  The totals of all accounts, logins and emails of the
+// user, including its aliases, are combined.
+func (r *Reader) UserSummary(ctx context.Context, org, user string, year, month int) (*types.UserSummaryResult, error) {
+	m, err := rollupMonth(year, month)
+	if err != nil {
+		return nil, err
+	}
+	id, err := r.contributorIdentities(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	rollups, err := r.db.Rollups(ctx, database.RollupQuery{
+		Organization: org,
+		UserIDs:      id.userIDs,
+		Authors:      append(append([]string{}, id.logins...), id.emails...),
+		Month:        m,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &types.UserSummaryResult{
+		User:         user,
+		Organization: org,
+		Year:         year,
+		Month:        month,
+		Repositories: make([]types.RepositorySummary, 0, len(rollups)),
+	}
+	repos := make(map[string]int, len(rollups))
+	for i := range rollups {
+		rollup := &rollups[i]
+		var repoStats types.SummaryStats
+		addRollupStats(&repoStats, rollup)
+		if repoStats == (types.SummaryStats{}) {
+			continue
+		}
+		idx, ok := repos[rollup.Repo]
+		if !ok {
+			idx = len(result.Repositories)
+			repos[rollup.Repo] = idx
+			result.Repositories = append(result.Repositories,
+				types.RepositorySummary{
+					Repository: rollup.Repo,
+				})
+		}
+		addRollupStats(&result.Repositories[idx].SummaryStats, rollup)
+		addRollupStats(&result.SummaryStats, rollup)
+	}
+	return result, nil
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRollupMonth(t *testing.T) {
+	tests := []struct {
+		year  int
+		month int
+		want  int
+		err   error
+	}{
+		{year: 2020, month: 1, want: 202001},
+		{year: 2020, month: 12, want: 202012},
+		{year: 2020, month: 0, err: ErrInvalidMonth},
+		{year: 2020, month: 13, err: ErrInvalidMonth},
+	}
+	for _, test := range tests {
+		got, err := rollupMonth(test.year, test.month)
+		if !errors.Is(err, test.err) {
+			t.Errorf("rollupMonth(%d, %d): got error %v, want %v",
+				test.year, test.month, err, test.err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("rollupMonth(%d, %d): got %d, want %d", test.year,
+				test.month, got, test.want)
+		}
+	}
+}
@@ -2,44 +2,124 @@ package server
 
 import (
 	"context"
-	"encoding/binary"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/decred/github-tracker/api"
 	"github.com/decred/github-tracker/database"
+	"github.com/decred/github-tracker/jsonrpc/types"
 )
 
+// Update syncs the pull requests of every repository of the passed
+// organization.  The progress of the sync and newly merged pull requests are
+// published to all subscriptions.
 func (s *Server) Update(ctx context.Context, org string) error {
+	if err := s.beginSync(); err != nil {
+		return err
+	}
+	defer s.syncs.Done()
+
+	progress := &types.SyncProgressNtfn{
+		Organization: org,
+	}
+	err := s.update(ctx, org, progress)
+
+	progress.Repository = ""
+	progress.Done = true
+	if err != nil {
+		progress.Error = err.Error()
+	}
+	s.publishSyncProgress(progress)
+
+	return err
+}
+
+// publishSyncProgress publishes a copy of the passed sync progress.
+func (s *Server) publishSyncProgress(progress *types.SyncProgressNtfn) {
+	ntfn := *progress
+	s.ntfns.publish(&ntfn)
+}
+
+// update syncs the organization and records its progress in the passed sync
+// progress.
+func (s *Server) update(ctx context.Context, org string, progress *types.SyncProgressNtfn) error {
 	// Fetch the organization's repositories
 	repos, err := s.tc.FetchOrgRepos(org)
 	if err != nil {
 		err = fmt.Errorf("FetchOrgRepos: %v", err)
 		return err
 	}
+	progress.Total = len(repos)
+
+	// The pull requests of purged users are skipped without fetching them.
+	// Other erased users are scrubbed when the pull requests are stored.
+	erased, err := s.DB.ErasedIdentities(ctx)
+	if err != nil {
+		return fmt.Errorf("ErasedIdentities: %v", err)
+	}
+	erasures := database.NewErasures(erased)
 
-	for _, repo := range repos {
-		log.Infof("%s", repo.Name)
+	for i, repo := range repos {
 		log.Infof("Syncing %s", repo.FullName)
+		progress.Repository = repo.FullName
+		progress.Synced = i
+		s.publishSyncProgress(progress)
 
-		// Let the current repo finish before exiting on cancel.
+		// Let the current repo finish before exiting on cancel or
+		// stop.
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
+		case <-s.stopSyncs:
+			return ErrStopping
 		default:
 		}
 
-		// Grab latest sync time
-		prs, err := s.tc.FetchPullsRequest(org, repo.Name)
+		start := time.Now()
+
+		// Record the repository before its pull requests.  Repositories
+		// are tracked by ID, so a renamed or transferred repository is
+		// updated in place rather than duplicated.
+		err = s.DB.UpsertRepository(ctx, convertAPIRepositoryToDbRepository(*repo))
 		if err != nil {
+			return fmt.Errorf("UpsertRepository: %v", err)
+		}
+
+		// Grab latest sync time.  When replaying from the archive,
+		// responses that were never archived are skipped.
+		prs, err := s.tc.FetchPullsRequest(org, repo.Name)
+		if errors.Is(err, api.ErrNotArchived) {
+			log.Warnf("Skipping %s: %v", repo.FullName, err)
+			continue
+		} else if err != nil {
 			return err
 		}
 
+		var merged []types.PullRequestInformation
 		for _, pr := range prs {
-			var prNum [8]byte
-			binary.LittleEndian.PutUint64(prNum[:], uint64(pr.Number))
+			if erasures.Purged(pr.User.ID, pr.User.Login) {
+				continue
+			}
+			dbPR, err := s.DB.PullRequestByNumber(ctx, repo.ID, pr.Number)
+			if err != nil && err != database.ErrNoPullRequestFound {
+				log.Errorf("error locating pull request: %v", err)
+				continue
+			}
+
+			// Only update if the PR is new or was updated more recently
+			// than what is currently stored.
+			if dbPR != nil &&
+				!parseTime(pr.UpdatedAt).After(time.Unix(dbPR.UpdatedAt, 0)) {
+				continue
+			}
+			log.Infof("\tUpdate PR %d", pr.Number)
 
 			apiPR, err := s.tc.FetchPullRequest(org, repo.Name, pr.Number)
-			if err != nil {
+			if errors.Is(err, api.ErrNotArchived) {
+				log.Warnf("Skipping PR %d: %v", pr.Number, err)
+				continue
+			} else if err != nil {
 				return err
 			}
 			dbPullRequest, err := convertAPIPullRequestToDbPullRequest(apiPR, *repo, org)
@@ -47,61 +127,75 @@ func (s *Server) Update(ctx context.Context, org string) error {
 				log.Errorf("error converting api PR to database: %v", err)
 				continue
 			}
-			dbPR, err := s.DB.PullRequestByURL(pr.URL)
-			if err != nil {
-				if err == database.ErrNoPullRequestFound {
-					prCommits, err := s.tc.FetchPullRequestCommits(org, repo.Name, pr.Number, parseTime(pr.UpdatedAt))
-					if err != nil {
-						return err
-					}
-
-					commits := convertAPICommitsToDbCommits(prCommits)
-					dbPullRequest.Commits = commits
-
-					prReviews, err := s.tc.FetchPullRequestReviews(org, repo.Name, pr.Number, parseTime(pr.UpdatedAt))
-					if err != nil {
-						panic(err)
-					}
-					reviews := convertAPIReviewsToDbReviews(prReviews, repo.Name, pr.Number)
-					dbPullRequest.Reviews = reviews
-
-					err = s.DB.NewPullRequest(dbPullRequest)
-					if err != nil {
-						log.Errorf("error adding new pull request: %v", err)
-						continue
-					}
-				} else {
-					log.Errorf("error locating pull request: %v", err)
-					continue
-				}
+
+			prCommits, err := s.tc.FetchPullRequestCommits(org, repo.Name, pr.Number, parseTime(pr.UpdatedAt))
+			if errors.Is(err, api.ErrNotArchived) {
+				log.Warnf("Skipping PR %d: %v", pr.Number, err)
+				continue
+			} else if err != nil {
+				return err
 			}
-			// Only update if dbPR is found and Uqpdated is more recent than what is currently stored.
-			if dbPR != nil && time.Unix(dbPR.UpdatedAt, 0).After(parseTime(pr.UpdatedAt)) {
-				log.Infof("\tUpdate PR %d", pr.Number)
-				prCommits, err := s.tc.FetchPullRequestCommits(org, repo.Name, pr.Number, parseTime(pr.UpdatedAt))
-				if err != nil {
-					return err
-				}
-
-				commits := convertAPICommitsToDbCommits(prCommits)
-				dbPullRequest.Commits = commits
-
-				prReviews, err := s.tc.FetchPullRequestReviews(org, repo.Name, pr.Number, parseTime(pr.UpdatedAt))
-				if err != nil {
-					panic(err)
-				}
-
-				reviews := convertAPIReviewsToDbReviews(prReviews, repo.Name, pr.Number)
-				dbPullRequest.Reviews = reviews
-
-				err = s.DB.UpdatePullRequest(dbPullRequest)
-				if err != nil {
-					log.Errorf("error updating new pull request: %v", err)
-					continue
-				}
+			dbPullRequest.Commits = convertAPICommitsToDbCommits(prCommits)
 
+			prReviews, err := s.tc.FetchPullRequestReviews(org, repo.Name, pr.Number, parseTime(pr.UpdatedAt))
+			if errors.Is(err, api.ErrNotArchived) {
+				log.Warnf("Skipping PR %d: %v", pr.Number, err)
+				continue
+			} else if err != nil {
+				return err
+			}
+			dbPullRequest.Reviews = convertAPIReviewsToDbReviews(prReviews, pr.URL, repo.Name, pr.Number)
+			dbPullRequest.Users = convertAPIPullRequestUsers(apiPR, prCommits, prReviews)
+
+			// Replace the stored PR along with all of its commits and
+			// reviews in a single transaction.  Erased users are
+			// scrubbed from the PR before it is stored and published.
+			err = s.DB.UpsertPullRequest(ctx, dbPullRequest)
+			if errors.Is(err, database.ErrUserErased) {
+				continue
+			} else if err != nil {
+				log.Errorf("error upserting pull request: %v", err)
+				continue
+			}
+			recordsWritten.WithLabelValues("pullrequest").Inc()
+			recordsWritten.WithLabelValues("review").Add(
+				float64(len(dbPullRequest.Reviews)))
+			recordsWritten.WithLabelValues("commit").Add(
+				float64(len(dbPullRequest.Commits)))
+			if dbPullRequest.MergedAt != 0 &&
+				(dbPR == nil || dbPR.MergedAt == 0) {
+				merged = append(merged,
+					convertDBPullRequestToPullRequest(dbPullRequest))
 			}
 		}
+		if len(merged) > 0 {
+			s.ntfns.publish(&types.NewMergesNtfn{
+				Organization: org,
+				Repository:   repo.FullName,
+				PullRequests: merged,
+			})
+		}
+
+		err = s.DB.RepositorySynced(ctx, repo.ID, time.Now().Unix())
+		if err != nil {
+			return fmt.Errorf("RepositorySynced: %v", err)
+		}
+		repositorySyncSeconds.WithLabelValues(org, repo.Name).Observe(
+			time.Since(start).Seconds())
+	}
+
+	progress.Synced = len(repos)
+
+	if len(repos) > 0 {
+		err = s.DB.OrganizationSynced(ctx, repos[0].Owner.ID, time.Now().Unix())
+		if err != nil {
+			return fmt.Errorf("OrganizationSynced: %v", err)
+		}
+	}
+
+	err = s.ApplyRetention(ctx)
+	if err != nil {
+		return fmt.Errorf("ApplyRetention: %v", err)
 	}
 
 	return nil
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/decred/github-tracker/database"
 	"github.com/decred/github-tracker/jsonrpc/types"
 )
 
@@ -16,19 +17,63 @@ func yearMonth(t time.Time) string {
 	return fmt.Sprintf("%d%02d", t.Year(), t.Month())
 }
 
-func (s *Server) UserInformation(ctx context.Context, org string, user string, year, month int) (*types.UserInformationResult, error) {
-	startDate := time.Date(year, time.Month(month), 0, 0, 0, 0, 0, time.UTC).Unix()
-	endDate := time.Date(year, time.Month(month+1), 0, 0, 0, 0, 0, time.UTC).Unix()
-	dbUserPRs, err := s.DB.PullRequestsByUserDates(user, startDate, endDate)
+// monthRange returns the UNIX timestamps of the start of the passed UTC
+// calendar month and of the start of the following month.  The month spans
+// from the start up to but excluding the end.
+func monthRange(year, month int) (int64, int64) {
+	startDate := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC).Unix()
+	endDate := time.Date(year, time.Month(month+1), 1, 0, 0, 0, 0, time.UTC).Unix()
+	return startDate, endDate
+}
+
+// UserInformation returns the pull requests, reviews and commits of the passed
+// login or contributor in the passed organization for the passed month.  All
+// organizations are included when the organization is empty.
+func (r *Reader) UserInformation(ctx context.Context, org string, user string, year, month int) (*types.UserInformationResult, error) {
+	id, err := r.contributorIdentities(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return r.userInformation(ctx, org, user, id, year, month)
+}
+
+// userInformation returns the user information of the passed identities.
+func (r *Reader) userInformation(ctx context.Context, org, user string, id *identities, year, month int) (*types.UserInformationResult, error) {
+	startDate, endDate := monthRange(year, month)
+	dbUserPRs, err := r.db.PullRequestsByUserDates(ctx, org, id.logins, startDate, endDate)
 	if err != nil {
 		return nil, err
 	}
-	dbReviews, err := s.DB.ReviewsByUserDates(user, startDate, endDate)
+	dbReviews, err := r.db.ReviewsByUserDates(ctx, org, id.logins, startDate, endDate)
 	if err != nil {
 		return nil, err
 	}
-	userInfo := convertPRsandReviewsToUserInformation(dbUserPRs, dbReviews)
+	dbCommits, err := r.db.CommitsByUserDates(ctx, org, id.logins, id.emails, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	userInfo := convertPRsReviewsAndCommitsToUserInformation(dbUserPRs, dbReviews, dbCommits)
 	userInfo.User = user
+	userInfo.Contributor = id.contributor
+	userInfo.Logins = id.logins
+	userInfo.Emails = id.emails
 	userInfo.Organization = org
 	return userInfo, nil
 }
+
+// userLogins resolves a login to all of the logins the same GitHub account has
+// been seen with, so that the history of renamed accounts is not split, along
+// with the ID of the account.  The login itself and a zero ID are returned
+// when the account is unknown.
+func (r *Reader) userLogins(ctx context.Context, login string) ([]string, int64, error) {
+	user, err := r.db.UserByLogin(ctx, login)
+	if err == database.ErrUserNotFound {
+		return []string{login}, 0, nil
+	} else if err != nil {
+		return nil, 0, err
+	}
+	if len(user.Logins) == 0 {
+		return []string{user.Login}, user.ID, nil
+	}
+	return user.Logins, user.ID, nil
+}
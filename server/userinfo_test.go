@@ -0,0 +1,28 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package server
+
+import "testing"
+
+func TestMonthRange(t *testing.T) {
+	tests := []struct {
+		year  int
+		month int
+		start int64
+		end   int64
+	}{
+		{year: 2020, month: 2, start: 1580515200, end: 1583020800},  // Leap year
+		{year: 2020, month: 5, start: 1588291200, end: 1590969600},  // 31 days
+		{year: 2020, month: 12, start: 1606780800, end: 1609459200}, // Year end
+		{year: 2021, month: 1, start: 1609459200, end: 1612137600},  // Year start
+	}
+	for _, test := range tests {
+		start, end := monthRange(test.year, test.month)
+		if start != test.start || end != test.end {
+			t.Errorf("monthRange(%d, %d): got [%d, %d), want [%d, %d)",
+				test.year, test.month, start, end, test.start, test.end)
+		}
+	}
+}